@@ -0,0 +1,207 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// attributionHeader is the first row of attribution.csv
+var attributionHeader = []string{"filename", "source_url", "page_url", "domain", "engine", "title", "author", "license", "license_url"}
+
+// attributionEntry is the credit information of a saved image
+type attributionEntry struct {
+	Filename   string
+	SourceURL  string
+	PageURL    string
+	Domain     string
+	Engine     string
+	Title      string
+	Author     string
+	License    string
+	LicenseURL string
+}
+
+// row returns the entry as an attribution.csv row
+func (e attributionEntry) row() []string {
+	return []string{e.Filename, e.SourceURL, e.PageURL, e.Domain, e.Engine, e.Title, e.Author, e.License, e.LicenseURL}
+}
+
+// attributionCollector gathers the entries of the images saved in a run. It is safe for concurrent use.
+type attributionCollector struct {
+	dir string // Output directory file names are made relative to
+
+	mu      sync.Mutex
+	entries []attributionEntry
+}
+
+// add records the image of a saved outcome
+func (c *attributionCollector) add(outcome downloadOutcome) {
+	if outcome.Replaced != "" {
+		c.remove(outcome.Replaced)
+		return
+	}
+	if outcome.Status != statusSaved {
+		return
+	}
+	result := outcome.Job.Result
+	filename := outcome.Path
+	if rel, err := filepath.Rel(c.dir, outcome.Path); err == nil {
+		filename = rel
+	}
+	domain := result.PageURL
+	if domain == "" {
+		domain = result.URL
+	}
+	if u, err := url.Parse(domain); err == nil {
+		domain = strings.TrimPrefix(u.Hostname(), "www.")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = append(c.entries, attributionEntry{
+		Filename:   filepath.ToSlash(filename),
+		SourceURL:  result.URL,
+		PageURL:    result.PageURL,
+		Domain:     domain,
+		Engine:     result.Engine,
+		Title:      result.Title,
+		Author:     result.Author,
+		License:    result.License,
+		LicenseURL: result.LicenseURL,
+	})
+}
+
+// remove drops the entry of an image that was removed after it was saved
+func (c *attributionCollector) remove(path string) {
+	filename := path
+	if rel, err := filepath.Rel(c.dir, path); err == nil {
+		filename = rel
+	}
+	filename = filepath.ToSlash(filename)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = slices.DeleteFunc(c.entries, func(e attributionEntry) bool { return e.Filename == filename })
+}
+
+// writeAttribution appends the run's entries to attribution.csv in the output directory and regenerates
+// ATTRIBUTION.md from the whole CSV, so reruns into the same directory keep crediting earlier images
+func writeAttribution(dir string, entries []attributionEntry) error {
+	csvPath := filepath.Join(dir, "attribution.csv")
+	all, err := readAttribution(csvPath)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(csvPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write attribution report: %v", err)
+	}
+	w := csv.NewWriter(file)
+	if info, err := file.Stat(); err == nil && info.Size() == 0 {
+		w.Write(attributionHeader)
+	}
+	for _, entry := range entries {
+		w.Write(entry.row())
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write attribution report: %v", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write attribution report: %v", err)
+	}
+
+	return writeAttributionMarkdown(filepath.Join(dir, "ATTRIBUTION.md"), append(all, entries...))
+}
+
+// readAttribution reads the entries of an existing attribution.csv, returning none if it doesn't exist
+func readAttribution(path string) ([]attributionEntry, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read attribution report: %v", err)
+	}
+	defer file.Close()
+
+	r := csv.NewReader(file)
+	r.FieldsPerRecord = len(attributionHeader)
+	var entries []attributionEntry
+	for first := true; ; first = false {
+		row, err := r.Read()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read attribution report: %v", err)
+		}
+		if first && row[0] == attributionHeader[0] {
+			continue
+		}
+		entries = append(entries, attributionEntry{
+			Filename: row[0], SourceURL: row[1], PageURL: row[2], Domain: row[3], Engine: row[4],
+			Title: row[5], Author: row[6], License: row[7], LicenseURL: row[8],
+		})
+	}
+}
+
+// writeAttributionMarkdown writes a human-readable credit list, one section per file sorted by name.
+// When a file was saved by several runs its latest entry is used.
+func writeAttributionMarkdown(path string, entries []attributionEntry) error {
+	latest := make(map[string]attributionEntry)
+	for _, entry := range entries {
+		latest[entry.Filename] = entry
+	}
+	filenames := make([]string, 0, len(latest))
+	for filename := range latest {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+
+	var b strings.Builder
+	b.WriteString("# Attribution\n\n")
+	b.WriteString("Sources and licenses of the downloaded images, as reported by the search engines and image providers.\n")
+	b.WriteString("Images without a license may still be protected by copyright; check the source page before reusing them.\n")
+	for _, filename := range filenames {
+		entry := latest[filename]
+		fmt.Fprintf(&b, "\n## %s\n\n", filename)
+		if entry.Title != "" {
+			fmt.Fprintf(&b, "- Title: %s\n", entry.Title)
+		}
+		if entry.Author != "" {
+			fmt.Fprintf(&b, "- Author: %s\n", entry.Author)
+		}
+		switch {
+		case entry.License != "" && entry.LicenseURL != "":
+			fmt.Fprintf(&b, "- License: [%s](%s)\n", entry.License, entry.LicenseURL)
+		case entry.License != "":
+			fmt.Fprintf(&b, "- License: %s\n", entry.License)
+		case entry.LicenseURL != "":
+			fmt.Fprintf(&b, "- License: <%s>\n", entry.LicenseURL)
+		default:
+			b.WriteString("- License: unknown\n")
+		}
+		if entry.PageURL != "" {
+			fmt.Fprintf(&b, "- Source page: <%s>\n", entry.PageURL)
+		}
+		fmt.Fprintf(&b, "- Image: <%s>\n", entry.SourceURL)
+		fmt.Fprintf(&b, "- Domain: %s\n", entry.Domain)
+		fmt.Fprintf(&b, "- Found with: %s\n", entry.Engine)
+	}
+
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write attribution report: %v", err)
+	}
+	return nil
+}
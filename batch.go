@@ -0,0 +1,310 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/selman92/image-searcher/pkg/searcher"
+)
+
+// batchQuery is a query of the run and the directory its images are saved in
+type batchQuery struct {
+	Query  string
+	Folder string // Output directory of the query, holding a folder per target
+	Limit  int    // Maximum number of images per engine, 0 to use -limit
+
+	Quota *labelQuota // Counts the saved images of the query's -dataset label, nil if the label has no target
+}
+
+// querySearch is a query of the run with the results of every target
+type querySearch struct {
+	batchQuery
+	Found     map[string][]searcher.Result
+	ScrapedAt map[string]time.Time // When each target's search returned
+}
+
+// loadQueries reads the queries of -queries-file, one per line, ignoring blank lines and # comments.
+// Every query gets its own folder in the output directory, named after the query.
+func loadQueries(path, out string) ([]batchQuery, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read queries file: %v", err)
+	}
+	defer f.Close()
+
+	var queries []batchQuery
+	folders := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		query, err := searcher.ParseQuery(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, line, err)
+		}
+		folder := queryFolder(query)
+		if other, ok := folders[folder]; ok {
+			if other == query {
+				return nil, fmt.Errorf("%s:%d: duplicate query %q", path, line, query)
+			}
+			return nil, fmt.Errorf("%s:%d: %q would share the folder %s with %q", path, line, query, folder, other)
+		}
+		folders[folder] = query
+		queries = append(queries, batchQuery{Query: query, Folder: filepath.Join(out, folder)})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read queries file: %v", err)
+	}
+	if len(queries) == 0 {
+		return nil, fmt.Errorf("no queries in %s", path)
+	}
+	return queries, nil
+}
+
+// loadDataset reads the label,query,limit rows of -dataset. Every query is saved in the folder of its label in the
+// output directory, so several rows with the same label add up to one class. The limit column is optional and is the
+// target of the label: the number of images its folder ends up with across all its rows and targets. It may be given
+// on any row of the label but must not differ between them. A leading label,query,limit header and lines starting
+// with # are skipped.
+func loadDataset(path, out string) ([]batchQuery, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dataset file: %v", err)
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.Comment = '#'
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	var queries []batchQuery
+	seen := make(map[[2]string]bool)
+	quotas := make(map[string]*labelQuota)
+	for first := true; ; first = false {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read dataset file: %v", err)
+		}
+		line, _ := r.FieldPos(0)
+		if first && strings.EqualFold(strings.TrimSpace(record[0]), "label") {
+			continue
+		}
+		if len(record) < 2 || len(record) > 3 {
+			return nil, fmt.Errorf("%s:%d: expected label,query,limit but found %d fields", path, line, len(record))
+		}
+
+		label := strings.TrimSpace(record[0])
+		if label == "" {
+			return nil, fmt.Errorf("%s:%d: empty label", path, line)
+		}
+		query, err := searcher.ParseQuery(record[1])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, line, err)
+		}
+		q := batchQuery{Query: query, Folder: filepath.Join(out, queryFolder(label))}
+		if len(record) == 3 && strings.TrimSpace(record[2]) != "" {
+			limit, err := strconv.Atoi(strings.TrimSpace(record[2]))
+			if err != nil || limit < 1 {
+				return nil, fmt.Errorf("%s:%d: invalid limit %q, expected a positive number", path, line, record[2])
+			}
+			if quota, ok := quotas[q.Folder]; ok && quota.target != limit {
+				return nil, fmt.Errorf("%s:%d: limit %d of label %q differs from its earlier limit %d", path, line, limit, label, quota.target)
+			}
+			if quotas[q.Folder] == nil {
+				quotas[q.Folder] = newLabelQuota(limit)
+			}
+		}
+		if seen[[2]string{q.Query, q.Folder}] {
+			return nil, fmt.Errorf("%s:%d: duplicate query %q for label %q", path, line, query, label)
+		}
+		seen[[2]string{q.Query, q.Folder}] = true
+		queries = append(queries, q)
+	}
+	if len(queries) == 0 {
+		return nil, fmt.Errorf("no queries in %s", path)
+	}
+	// Every row of a label with a target searches for up to the whole target, as it may have to fill it alone
+	for i, q := range queries {
+		if quota := quotas[q.Folder]; quota != nil {
+			queries[i].Limit, queries[i].Quota = quota.target, quota
+		}
+	}
+	return queries, nil
+}
+
+// labelQuota counts the images saved for a -dataset label across its rows and targets, so downloads of the label
+// stop once its folder holds the target number of images
+type labelQuota struct {
+	target int
+
+	mu      sync.Mutex
+	changed *sync.Cond // Signalled when a download of the label ends
+	saved   int        // Images of the label saved so far
+	pending int        // Downloads of the label in flight
+}
+
+// newLabelQuota returns the quota of a label that should end up with target images
+func newLabelQuota(target int) *labelQuota {
+	q := &labelQuota{target: target}
+	q.changed = sync.NewCond(&q.mu)
+	return q
+}
+
+// acquire reports whether a download of the label may start, false once the target is reached. While the downloads
+// in flight could still reach the target it waits for them, so no more images are saved than the target.
+// A nil quota always allows the download, and so does a cancelled ctx so the download ends as cancelled.
+func (q *labelQuota) acquire(ctx context.Context) bool {
+	if q == nil {
+		return true
+	}
+	stop := context.AfterFunc(ctx, func() {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		q.changed.Broadcast()
+	})
+	defer stop()
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for q.saved < q.target && q.saved+q.pending >= q.target && ctx.Err() == nil {
+		q.changed.Wait()
+	}
+	if q.saved >= q.target && ctx.Err() == nil {
+		return false
+	}
+	q.pending++
+	return true
+}
+
+// release ends a download started by acquire, counting its image if it was saved
+func (q *labelQuota) release(saved bool) {
+	if q == nil {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.pending--
+	if saved {
+		q.saved++
+	}
+	q.changed.Broadcast()
+}
+
+// remove uncounts a saved image of the label that was removed since, e.g. for an identical one of a preferred engine
+func (q *labelQuota) remove() {
+	if q == nil {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.saved--
+	q.changed.Broadcast()
+}
+
+// templateQueries returns the queries of a -query template filled in with every combination of the -vars values.
+// Every query gets its own folder in the output directory, named after the query.
+func templateQueries(template, vars, out string) ([]batchQuery, error) {
+	values, err := loadQueryVars(vars, template)
+	if err != nil {
+		return nil, err
+	}
+	var queries []batchQuery
+	folders := make(map[string]string)
+	for _, text := range expandTemplate(template, values) {
+		query, err := searcher.ParseQuery(text)
+		if err != nil {
+			return nil, err
+		}
+		folder := queryFolder(query)
+		if _, ok := folders[folder]; ok {
+			slog.Debug("Skipping repeated query", "query", query)
+			continue
+		}
+		folders[folder] = query
+		queries = append(queries, batchQuery{Query: query, Folder: filepath.Join(out, folder)})
+	}
+	return queries, nil
+}
+
+// streamQueries reads queries from r, one per line, and sends each as soon as its line is complete, so the run
+// can search and download a query before the next one is written. Blank lines and # comments are ignored and
+// invalid queries are logged and skipped instead of ending the stream. The channel is closed at the end of r
+// or once ctx is cancelled.
+func streamQueries(ctx context.Context, r io.Reader, out string) <-chan batchQuery {
+	queries := make(chan batchQuery)
+	go func() {
+		defer close(queries)
+		scanner := bufio.NewScanner(r)
+		for line := 1; scanner.Scan(); line++ {
+			text := strings.TrimSpace(scanner.Text())
+			if text == "" || strings.HasPrefix(text, "#") {
+				continue
+			}
+			query, err := searcher.ParseQuery(text)
+			if err != nil {
+				slog.Error("Skipping invalid query", "line", line, "error", err)
+				continue
+			}
+			select {
+			case queries <- batchQuery{Query: query, Folder: filepath.Join(out, queryFolder(query))}:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			slog.Error("Failed to read queries", "error", err)
+		}
+	}()
+	return queries
+}
+
+// queueJobs returns the download jobs of the results of every search, in the order of the searches and targets.
+// With create set the folder of every target with results is created first.
+func queueJobs(searches []querySearch, targets []string, create bool) []downloadJob {
+	var jobs []downloadJob
+	for _, s := range searches {
+		for _, target := range targets {
+			results, ok := s.Found[target]
+			if !ok {
+				continue
+			}
+			folder := filepath.Join(s.Folder, target)
+			if create {
+				if err := os.MkdirAll(folder, os.ModePerm); err != nil {
+					fmt.Fprintf(os.Stderr, "Failed to create folder: %v\n", err)
+					continue
+				}
+			}
+			for i, result := range results {
+				jobs = append(jobs, downloadJob{Result: result, Folder: folder, Index: i + 1, ScrapedAt: s.ScrapedAt[target], Quota: s.Quota})
+			}
+		}
+	}
+	return jobs
+}
+
+// queryFolder returns the folder name of a query, with characters that are unsafe in file names replaced
+func queryFolder(query string) string {
+	folder := strings.Trim(unsafeNameChars.ReplaceAllString(query, "_"), " .")
+	if folder == "" {
+		return "_"
+	}
+	return folder
+}
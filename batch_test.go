@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// writeDataset writes the CSV to a dataset file and returns its path
+func writeDataset(t *testing.T, csv string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "dataset.csv")
+	if err := os.WriteFile(path, []byte(csv), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadDatasetLabelTargets(t *testing.T) {
+	path := writeDataset(t, "label,query,limit\n# cats\ntabby,tabby cat\ntabby,striped cat,5\ncalico,calico cat\n")
+	queries, err := loadDataset(path, "images")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queries) != 3 {
+		t.Fatalf("got %d queries, want 3", len(queries))
+	}
+	tabby, striped, calico := queries[0], queries[1], queries[2]
+	if tabby.Folder != filepath.Join("images", "tabby") || striped.Folder != tabby.Folder {
+		t.Errorf("got folders %s and %s, want both in images/tabby", tabby.Folder, striped.Folder)
+	}
+	// The limit of the second row is the target of the label, shared with its first row
+	if tabby.Quota == nil || tabby.Quota != striped.Quota || tabby.Quota.target != 5 {
+		t.Errorf("got quotas %+v and %+v, want one shared target of 5", tabby.Quota, striped.Quota)
+	}
+	if tabby.Limit != 5 || striped.Limit != 5 {
+		t.Errorf("got limits %d and %d, want the target of 5 for both rows", tabby.Limit, striped.Limit)
+	}
+	if calico.Quota != nil || calico.Limit != 0 {
+		t.Errorf("got quota %+v and limit %d for a label without a target", calico.Quota, calico.Limit)
+	}
+}
+
+func TestLoadDatasetErrors(t *testing.T) {
+	tests := []struct {
+		csv  string
+		want string
+	}{
+		{"tabby,tabby cat,5\ntabby,striped cat,10\n", "dataset.csv:2: limit 10 of label \"tabby\" differs from its earlier limit 5"},
+		{"tabby,tabby cat,0\n", "dataset.csv:1: invalid limit"},
+		{"tabby,tabby cat,many\n", "dataset.csv:1: invalid limit"},
+		{"tabby,tabby cat\ntabby,tabby cat\n", "dataset.csv:2: duplicate query"},
+		{",tabby cat\n", "dataset.csv:1: empty label"},
+		{"tabby\n", "dataset.csv:1: expected label,query,limit"},
+		{"# nothing\n", "no queries"},
+	}
+	for _, tt := range tests {
+		if _, err := loadDataset(writeDataset(t, tt.csv), "images"); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%q: got error %v, want one containing %q", tt.csv, err, tt.want)
+		}
+	}
+}
+
+func TestDownloadImagesStopsAtLabelTarget(t *testing.T) {
+	template, err := parseNameTemplate("{engine}-{index}{ext}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := downloadOptions{Workers: 4, NameTemplate: template, OnConflict: conflictOverwrite, Progress: newProgress(true)}
+	quota := newLabelQuota(5)
+	folder := t.TempDir()
+
+	// Two rows of the label, each with four images of two engines
+	var jobs []downloadJob
+	for i := 0; i < 16; i++ {
+		job := capturedJob(folder, []string{"bing", "google"}[i%2], i+1, testPNG(t, color.RGBA{uint8(i), 0, 0, 255}))
+		job.Result.URL = fmt.Sprintf("https://example.com/%d.png", i)
+		job.Quota = quota
+		jobs = append(jobs, job)
+	}
+	var mu sync.Mutex
+	var saved, skipped int
+	opts.Report = func(outcome downloadOutcome) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch outcome.Status {
+		case statusSaved:
+			saved++
+		case statusSkipped:
+			skipped++
+		}
+	}
+	downloadImages(context.Background(), nil, nil, jobs, opts)
+
+	if saved != 5 || skipped != 11 {
+		t.Errorf("got %d saved and %d skipped, want 5 and 11", saved, skipped)
+	}
+	if entries, _ := os.ReadDir(folder); len(entries) != 5 {
+		t.Errorf("got %d files in the label's folder, want 5", len(entries))
+	}
+}
+
+func TestLabelQuotaWaitsForDownloadsInFlight(t *testing.T) {
+	quota := newLabelQuota(2)
+	ctx := context.Background()
+	if !quota.acquire(ctx) || !quota.acquire(ctx) {
+		t.Fatal("refused a download below the target")
+	}
+
+	started := make(chan bool)
+	go func() { started <- quota.acquire(ctx) }()
+	// The first download fails, so the waiting one may take its place
+	quota.release(false)
+	if !<-started {
+		t.Fatal("refused a download after one in flight failed")
+	}
+	quota.release(true)
+	quota.release(true)
+	if quota.acquire(ctx) {
+		t.Error("allowed a download after the target was reached")
+	}
+
+	// An image removed since no longer counts
+	quota.remove()
+	if !quota.acquire(ctx) {
+		t.Error("refused a download after a saved image was removed")
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// checksumsFile is the name of the checksum list written to the output directory
+const checksumsFile = "SHA256SUMS"
+
+// writeChecksums writes SHA256SUMS to the output directory, listing every file in it in the format of sha256sum,
+// so `sha256sum -c SHA256SUMS` verifies the directory later. Unfinished downloads and temporary files are left out.
+func writeChecksums(dir string) error {
+	var lines []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if rel == checksumsFile || strings.HasSuffix(path, partSuffix) || strings.HasSuffix(path, ".tmp") {
+			return nil
+		}
+
+		sum, err := fileSHA256(path)
+		if err != nil {
+			return err
+		}
+		lines = append(lines, sum+"  "+filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to compute checksums: %v", err)
+	}
+	sort.Slice(lines, func(i, j int) bool { return lines[i][66:] < lines[j][66:] })
+
+	tmpPath := filepath.Join(dir, checksumsFile+".tmp")
+	if err := os.WriteFile(tmpPath, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write checksums: %v", err)
+	}
+	if err := os.Rename(tmpPath, filepath.Join(dir, checksumsFile)); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write checksums: %v", err)
+	}
+	return nil
+}
+
+// fileSHA256 returns the hex-encoded SHA-256 of the file contents
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+
+	"github.com/selman92/image-searcher/pkg/searcher"
+)
+
+// duplicateImage records a download that was dropped because an identical file was already saved in the run
+type duplicateImage struct {
+	SourceURL   string `json:"source_url"`
+	Engine      string `json:"engine"`
+	SHA256      string `json:"sha256"`
+	DuplicateOf string `json:"duplicate_of"`
+}
+
+// contentIndex remembers the SHA-256 of every image saved in the run to drop exact duplicates,
+// which engines often return from different URLs. With a store it also knows the images of earlier runs.
+// Among identical images of the run, the one of the engine first in the engine order is kept, whichever
+// download finishes first.
+type contentIndex struct {
+	store *dedupeStore   // Cross-run database, nil if not used
+	ranks map[string]int // Position of each engine in the engine order
+
+	mu         sync.Mutex
+	kept       map[string]*keptImage // Saved image by content hash
+	hashes     map[string]string     // Content hash by saved path, to forget images overwritten later in the run
+	duplicates []duplicateImage
+}
+
+// keptImage is an image saved in the run that identical images are dropped in favor of
+type keptImage struct {
+	path       string
+	job        downloadJob
+	bytes      int64
+	sha256     string
+	settled    bool   // The job that saved it has reported it as saved
+	replacedBy string // Path of the identical image of a preferred engine that replaced it, empty if none
+}
+
+// newContentIndex returns an index remembering images in store if set, keeping the images of the engines
+// earlier in order over identical ones
+func newContentIndex(store *dedupeStore, order []string) *contentIndex {
+	ranks := make(map[string]int, len(order))
+	for i, engine := range order {
+		ranks[engine] = i
+	}
+	return &contentIndex{store: store, ranks: ranks, kept: make(map[string]*keptImage), hashes: make(map[string]string)}
+}
+
+// rank returns the position of the engine in the engine order, engines missing from it coming last
+func (c *contentIndex) rank(engine string) int {
+	if rank, ok := c.ranks[engine]; ok {
+		return rank
+	}
+	return len(c.ranks)
+}
+
+// contentClaim is what contentIndex.save did with an image
+type contentClaim struct {
+	duplicateOf string     // Path of the identical image kept instead, empty if the image was saved
+	image       *keptImage // The saved image, which its job settles before reporting it
+	replaced    *keptImage // Settled identical image of a less preferred engine the saved one replaced, to remove
+}
+
+// save moves the downloaded image of the job from its .part file to path and registers it under its hash.
+// If an identical image was saved before at another path, in this run or in one recorded in the store, it deletes
+// the download, records the duplicate and returns the path of the copy kept instead, unless the copy was saved in
+// this run for an engine later in the engine order; then the image is saved and replaces it. The check and the
+// move happen under one lock, so identical images finishing at once are never both kept.
+func (c *contentIndex) save(img *savedImage, job downloadJob, path string) (contentClaim, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	result := job.Result
+	var original string
+	previous, ok := c.kept[img.SHA256]
+	if ok {
+		original = previous.path
+	} else if c.store != nil {
+		var err error
+		if original, ok, err = c.store.pathForHash(img.SHA256); err != nil {
+			slog.Error("Failed to look up hash in the dedupe database", "sha256", img.SHA256, "error", err)
+		}
+	}
+
+	// An image saved over its own earlier copy, e.g. with -on-conflict overwrite, is that copy and not a duplicate
+	if ok && original == path {
+		ok, previous = false, nil
+	}
+	if ok && (previous == nil || c.rank(result.Engine) >= c.rank(previous.job.Result.Engine)) {
+		if err := os.Remove(img.Path); err != nil {
+			slog.Error("Failed to remove duplicate image", "path", img.Path, "error", err)
+		}
+		c.duplicates = append(c.duplicates, duplicateImage{SourceURL: result.URL, Engine: result.Engine, SHA256: img.SHA256, DuplicateOf: original})
+		c.record(result, img.SHA256, original)
+		return contentClaim{duplicateOf: original}, nil
+	}
+
+	if err := moveImage(img, path); err != nil {
+		return contentClaim{}, err
+	}
+	// A different image saved at the path before is gone now
+	if hash, ok := c.hashes[path]; ok && hash != img.SHA256 {
+		delete(c.kept, hash)
+	}
+	claim := contentClaim{image: &keptImage{path: path, job: job, bytes: img.Bytes, sha256: img.SHA256}}
+	c.kept[img.SHA256] = claim.image
+	c.hashes[path] = img.SHA256
+	c.record(result, img.SHA256, path)
+
+	if previous != nil {
+		// The image of a less preferred engine gives way. If its job is still finishing, the job drops it when
+		// settling; otherwise the caller removes it.
+		previous.replacedBy = path
+		delete(c.hashes, previous.path)
+		c.duplicates = append(c.duplicates, duplicateImage{SourceURL: previous.job.Result.URL, Engine: previous.job.Result.Engine, SHA256: img.SHA256, DuplicateOf: path})
+		if c.store != nil {
+			if err := c.store.replacePath(img.SHA256, previous.path, path); err != nil {
+				slog.Error("Failed to update image in the dedupe database", "sha256", img.SHA256, "error", err)
+			}
+		}
+		if previous.settled {
+			claim.replaced = previous
+		}
+	}
+	return claim, nil
+}
+
+// settle marks the saved image as reported by its job with the size and checksum of img, which differ from the
+// downloaded ones if metadata was embedded, and returns the path of the identical image of a preferred engine that
+// replaced it in the meantime, empty if none did
+func (c *contentIndex) settle(image *keptImage, img *savedImage) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	image.settled = true
+	image.bytes, image.sha256 = img.Bytes, img.SHA256
+	return image.replacedBy
+}
+
+// record stores the image in the cross-run database, if one is used
+func (c *contentIndex) record(result searcher.Result, hash, path string) {
+	if c.store == nil {
+		return
+	}
+	if err := c.store.record(result.URL, hash, path, result.Engine, result.Query); err != nil {
+		slog.Error("Failed to record image in the dedupe database", "url", result.URL, "error", err)
+	}
+}
+
+// writeDuplicateReport writes the dropped duplicates as a JSON array
+func writeDuplicateReport(path string, duplicates []duplicateImage) error {
+	data, err := json.MarshalIndent(duplicates, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode duplicate report: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write duplicate report: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/selman92/image-searcher/pkg/searcher"
+)
+
+// testPNG returns a small PNG filled with the color, so images of different colors have different contents
+func testPNG(t *testing.T, c color.Color) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	for x := 0; x < 16; x++ {
+		for y := 0; y < 16; y++ {
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// capturedJob returns a job for an image captured from the browser, which is saved without a download
+func capturedJob(folder, engine string, index int, data []byte) downloadJob {
+	return downloadJob{
+		Result: searcher.Result{URL: "https://example.com/" + engine + ".png", Engine: engine, Query: "cats", Data: data},
+		Folder: folder,
+		Index:  index,
+	}
+}
+
+func TestDownloadJobImageOverwritesOwnCopy(t *testing.T) {
+	folder := t.TempDir()
+	template, err := parseNameTemplate("{query}{ext}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := downloadOptions{NameTemplate: template, OnConflict: conflictOverwrite, DedupeContent: true}
+	index := newContentIndex(nil, nil)
+	data := testPNG(t, color.White)
+
+	for i := 1; i <= 2; i++ {
+		outcome := downloadJobImage(context.Background(), nil, nil, index, capturedJob(folder, "bing", i, data), opts)
+		if outcome.Status != statusSaved {
+			t.Fatalf("download %d: got %s (%s), want saved", i, outcome.Status, outcome.Reason)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(folder, "cats.png")); err != nil {
+		t.Errorf("the only copy was deleted: %v", err)
+	}
+	if len(index.duplicates) != 0 {
+		t.Errorf("got duplicates %v, want none", index.duplicates)
+	}
+}
+
+func TestDownloadJobImageDropsDuplicate(t *testing.T) {
+	folder := t.TempDir()
+	template, err := parseNameTemplate("{engine}{ext}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := downloadOptions{NameTemplate: template, OnConflict: conflictOverwrite, DedupeContent: true}
+	index := newContentIndex(nil, []string{"google", "bing"})
+	data := testPNG(t, color.White)
+
+	first := downloadJobImage(context.Background(), nil, nil, index, capturedJob(folder, "google", 1, data), opts)
+	second := downloadJobImage(context.Background(), nil, nil, index, capturedJob(folder, "bing", 1, data), opts)
+	if first.Status != statusSaved || second.Status != statusDuplicate {
+		t.Fatalf("got %s and %s, want saved and duplicate", first.Status, second.Status)
+	}
+	if _, err := os.Stat(filepath.Join(folder, "google.png")); err != nil {
+		t.Errorf("the kept copy is missing: %v", err)
+	}
+	entries, _ := os.ReadDir(folder)
+	if len(entries) != 1 {
+		t.Errorf("got %d files, want the kept copy only", len(entries))
+	}
+}
+
+func TestContentIndexForgetsOverwrittenImage(t *testing.T) {
+	folder := t.TempDir()
+	index := newContentIndex(nil, nil)
+	path := filepath.Join(folder, "cats.png")
+	save := func(hash, target string) string {
+		t.Helper()
+		part := filepath.Join(folder, hash+partSuffix)
+		if err := os.WriteFile(part, []byte(hash), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		claim, err := index.save(&savedImage{Path: part, SHA256: hash}, downloadJob{Result: searcher.Result{Engine: "bing"}}, target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return claim.duplicateOf
+	}
+
+	save("aaaa", path)
+	// A different image overwrites the first one, so the first one's contents are no longer on disk
+	save("bbbb", path)
+	if original := save("aaaa", filepath.Join(folder, "dogs.png")); original != "" {
+		t.Errorf("got a duplicate of %s, whose contents were overwritten", original)
+	}
+	if original := save("bbbb", filepath.Join(folder, "birds.png")); original != path {
+		t.Errorf("got original %q, want %s", original, path)
+	}
+}
+
+func TestDownloadJobImagePrefersEngine(t *testing.T) {
+	folder := t.TempDir()
+	template, err := parseNameTemplate("{engine}{ext}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reported []downloadOutcome
+	opts := downloadOptions{
+		NameTemplate:  template,
+		OnConflict:    conflictOverwrite,
+		DedupeContent: true,
+		Report:        func(outcome downloadOutcome) { reported = append(reported, outcome) },
+	}
+	index := newContentIndex(nil, []string{"google", "bing"})
+	data := testPNG(t, color.White)
+
+	// The less preferred engine's copy finishes first and is replaced when the preferred one arrives
+	first := downloadJobImage(context.Background(), nil, nil, index, capturedJob(folder, "bing", 1, data), opts)
+	second := downloadJobImage(context.Background(), nil, nil, index, capturedJob(folder, "google", 1, data), opts)
+	if first.Status != statusSaved || second.Status != statusSaved {
+		t.Fatalf("got %s and %s, want both saved", first.Status, second.Status)
+	}
+	if _, err := os.Stat(filepath.Join(folder, "bing.png")); !os.IsNotExist(err) {
+		t.Errorf("the replaced copy of bing is still there")
+	}
+	if _, err := os.Stat(filepath.Join(folder, "google.png")); err != nil {
+		t.Errorf("the copy of google is missing: %v", err)
+	}
+	if len(reported) != 1 || reported[0].Job.Result.Engine != "bing" || reported[0].Replaced != first.Path || reported[0].Status != statusDuplicate {
+		t.Errorf("got reported outcomes %+v, want a correction for bing", reported)
+	}
+	if len(index.duplicates) != 1 || index.duplicates[0].Engine != "bing" || index.duplicates[0].DuplicateOf != second.Path {
+		t.Errorf("got duplicates %+v, want bing as a duplicate of google", index.duplicates)
+	}
+
+	stats := newSummaryCollector([]string{"google", "bing"})
+	stats.add(first)
+	stats.add(second)
+	stats.add(reported[0])
+	if total := stats.summary(nil).Total; total.Saved != 1 || total.Duplicates != 1 {
+		t.Errorf("got %d saved and %d duplicates, want 1 and 1", total.Saved, total.Duplicates)
+	}
+}
+
+func TestContentIndexReplacesUnsettledImage(t *testing.T) {
+	folder := t.TempDir()
+	index := newContentIndex(nil, []string{"google", "bing"})
+	save := func(engine string) contentClaim {
+		t.Helper()
+		part := filepath.Join(folder, engine+partSuffix)
+		if err := os.WriteFile(part, []byte("same"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		claim, err := index.save(&savedImage{Path: part, SHA256: "aaaa"}, downloadJob{Result: searcher.Result{Engine: engine}}, filepath.Join(folder, engine+".png"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return claim
+	}
+
+	bing := save("bing")
+	// The preferred copy arrives while the job of bing is still finishing, so that job drops its own copy
+	google := save("google")
+	if google.duplicateOf != "" || google.replaced != nil {
+		t.Fatalf("got %+v, want google saved with nothing left to remove", google)
+	}
+	if replacedBy := index.settle(bing.image, &savedImage{SHA256: "aaaa"}); replacedBy != google.image.path {
+		t.Errorf("bing settled as replaced by %q, want %s", replacedBy, google.image.path)
+	}
+	if replacedBy := index.settle(google.image, &savedImage{SHA256: "aaaa"}); replacedBy != "" {
+		t.Errorf("google settled as replaced by %q", replacedBy)
+	}
+
+	// A later copy of a less preferred engine is a duplicate of google's
+	if claim := save("yandex"); claim.duplicateOf != google.image.path {
+		t.Errorf("got duplicate of %q, want %s", claim.duplicateOf, google.image.path)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// dedupeSchema creates the table of images collected by earlier runs
+const dedupeSchema = `
+CREATE TABLE IF NOT EXISTS images (
+	url           TEXT NOT NULL,
+	sha256        TEXT NOT NULL,
+	path          TEXT NOT NULL,
+	engine        TEXT NOT NULL,
+	query         TEXT NOT NULL,
+	downloaded_at TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS images_url ON images (url);
+CREATE INDEX IF NOT EXISTS images_sha256 ON images (sha256);
+`
+
+// dedupeStore is an SQLite database of the URLs and content hashes of every image downloaded,
+// letting later runs skip URLs they already fetched and drop images they already have
+type dedupeStore struct {
+	db *sql.DB
+}
+
+// openDedupeStore opens the database at path, creating it if needed
+func openDedupeStore(path string) (*dedupeStore, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dedupe database: %v", err)
+	}
+	// SQLite allows a single writer, so serialise access instead of failing with "database is locked"
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(dedupeSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialise dedupe database: %v", err)
+	}
+	return &dedupeStore{db: db}, nil
+}
+
+// Close closes the database
+func (s *dedupeStore) Close() error {
+	return s.db.Close()
+}
+
+// hasURL reports whether the URL was downloaded before
+func (s *dedupeStore) hasURL(url string) (bool, error) {
+	var exists bool
+	err := s.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM images WHERE url = ?)`, url).Scan(&exists)
+	return exists, err
+}
+
+// pathForHash returns where an image with the content hash was saved before, if it was
+func (s *dedupeStore) pathForHash(hash string) (string, bool, error) {
+	var path string
+	err := s.db.QueryRow(`SELECT path FROM images WHERE sha256 = ? LIMIT 1`, hash).Scan(&path)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return path, true, nil
+}
+
+// replacePath points the images recorded with the content hash at the copy saved at path instead of oldPath,
+// which was removed
+func (s *dedupeStore) replacePath(hash, oldPath, path string) error {
+	_, err := s.db.Exec(`UPDATE images SET path = ? WHERE sha256 = ? AND path = ?`, path, hash, oldPath)
+	return err
+}
+
+// record stores a downloaded image. Duplicates are recorded with the path of the copy that was kept,
+// so their URL is skipped in later runs too.
+func (s *dedupeStore) record(url, hash, path, engine, query string) error {
+	_, err := s.db.Exec(`INSERT INTO images (url, sha256, path, engine, query, downloaded_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		url, hash, path, engine, query, time.Now().UTC())
+	return err
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/selman92/image-searcher/pkg/searcher"
+)
+
+// domainFilter keeps or drops results by the domain of the image or of the page it was found on.
+// A domain also matches its subdomains, so "example.com" matches "cdn.example.com".
+type domainFilter struct {
+	include []string // Results have to match one of these domains, empty to allow every domain
+	exclude []string // Results matching one of these domains are dropped
+}
+
+// newDomainFilter returns the filter for the comma-separated domain lists of -include-domains and -exclude-domains
+func newDomainFilter(include, exclude string) domainFilter {
+	return domainFilter{include: parseDomains(include), exclude: parseDomains(exclude)}
+}
+
+// parseDomains splits a comma-separated domain list, ignoring the case, blanks, "*." wildcards and leading dots
+func parseDomains(list string) []string {
+	var domains []string
+	for _, domain := range strings.Split(list, ",") {
+		domain = strings.TrimLeft(strings.TrimPrefix(strings.ToLower(strings.TrimSpace(domain)), "*."), ".")
+		if domain != "" {
+			domains = append(domains, domain)
+		}
+	}
+	return domains
+}
+
+// active reports whether the filter drops anything
+func (f domainFilter) active() bool {
+	return len(f.include) > 0 || len(f.exclude) > 0
+}
+
+// allows reports whether a result passes the filter. The page URL is checked along with the image URL
+// because many sites serve their images from a CDN on another domain.
+func (f domainFilter) allows(result searcher.Result) bool {
+	urls := []string{result.URL, result.PageURL}
+	if len(f.include) > 0 && !matchesDomain(urls, f.include) {
+		return false
+	}
+	return !matchesDomain(urls, f.exclude)
+}
+
+// apply returns the results that pass the filter
+func (f domainFilter) apply(results []searcher.Result) []searcher.Result {
+	if !f.active() {
+		return results
+	}
+	var kept []searcher.Result
+	for _, result := range results {
+		if f.allows(result) {
+			kept = append(kept, result)
+		}
+	}
+	return kept
+}
+
+// matchesDomain reports whether the host of any of the URLs is one of the domains or a subdomain of one.
+// IP addresses only match exactly.
+func matchesDomain(urls []string, domains []string) bool {
+	for _, rawURL := range urls {
+		u, err := url.Parse(rawURL)
+		if err != nil || u.Hostname() == "" {
+			continue
+		}
+		host := strings.ToLower(u.Hostname())
+		for _, domain := range domains {
+			if host == domain || (net.ParseIP(host) == nil && strings.HasSuffix(host, "."+domain)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// siteQuery returns the query restricted to the site with the site: operator, or the query itself if site is empty
+func siteQuery(query, site string) string {
+	if site == "" {
+		return query
+	}
+	return query + " site:" + site
+}
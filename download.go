@@ -0,0 +1,858 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/selman92/image-searcher/pkg/searcher"
+)
+
+// CreateFolder creates the directory to save images
+func createFolder(folder string) error {
+	if _, err := os.Stat(folder); os.IsNotExist(err) {
+		err := os.Mkdir(folder, os.ModePerm)
+		if err != nil {
+			return fmt.Errorf("failed to create folder: %v", err)
+		}
+	}
+	return nil
+}
+
+// httpClientConfig holds the transport settings of the HTTP client shared by all downloads
+type httpClientConfig struct {
+	ConnectTimeout    time.Duration  // Time allowed to establish a connection, including the TLS handshake
+	ResponseTimeout   time.Duration  // Time allowed for the server to send response headers once the request is written
+	DownloadTimeout   time.Duration  // Time allowed for a whole download including the body, 0 for no limit
+	MaxIdlePerHost    int            // Idle connections kept open per host for reuse
+	DisableKeepAlives bool           // Opens a new connection for every download
+	DisableHTTP2      bool           // Forces HTTP/1.1, for servers with broken HTTP/2 support
+	MaxBandwidth      float64        // Aggregate download throughput in bytes per second, 0 for no limit
+	Proxy             *url.URL       // Proxy for all downloads, nil to use the environment's HTTP_PROXY and HTTPS_PROXY
+	Proxies           *proxyPool     // Rotates downloads across proxies instead of using Proxy, nil if not used
+	MaxRedirects      int            // Redirects followed per request, 0 to fail on any redirect
+	RootCAs           *x509.CertPool // Certificate authorities trusted by TLS connections, nil for the system's
+	Insecure          bool           // Skips TLS certificate verification entirely
+}
+
+// loadCACerts returns the system's certificate authorities plus those in the PEM file,
+// for networks that intercept TLS with their own CA
+func loadCACerts(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA certificates: %v", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no PEM certificates found in %s", path)
+	}
+	return pool, nil
+}
+
+// newHTTPClient returns the client shared by all downloads, so connections are reused across engines
+// and a hung server can never stall a download forever
+func newHTTPClient(cfg httpClientConfig) *http.Client {
+	dialer := &net.Dialer{Timeout: cfg.ConnectTimeout, KeepAlive: 30 * time.Second}
+	proxy := http.ProxyFromEnvironment
+	if cfg.Proxies != nil {
+		proxy = contextProxy
+	} else if cfg.Proxy != nil {
+		proxy = http.ProxyURL(cfg.Proxy)
+	}
+	transport := &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   cfg.ConnectTimeout,
+		ResponseHeaderTimeout: cfg.ResponseTimeout,
+		ExpectContinueTimeout: time.Second,
+		IdleConnTimeout:       90 * time.Second,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   cfg.MaxIdlePerHost,
+		DisableKeepAlives:     cfg.DisableKeepAlives,
+		ForceAttemptHTTP2:     !cfg.DisableHTTP2,
+		TLSClientConfig:       &tls.Config{RootCAs: cfg.RootCAs, InsecureSkipVerify: cfg.Insecure},
+	}
+	if cfg.DisableHTTP2 {
+		// A non-nil empty map turns off the transport's automatic HTTP/2 upgrade
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+
+	var roundTripper http.RoundTripper = transport
+	if cfg.MaxBandwidth > 0 {
+		roundTripper = &throttledTransport{base: transport, limiter: newBandwidthLimiter(cfg.MaxBandwidth)}
+	}
+	checkRedirect := func(req *http.Request, via []*http.Request) error {
+		if len(via) > cfg.MaxRedirects {
+			return fmt.Errorf("stopped after %d redirects", cfg.MaxRedirects)
+		}
+		return nil
+	}
+	return &http.Client{Transport: roundTripper, Timeout: cfg.DownloadTimeout, CheckRedirect: checkRedirect}
+}
+
+// conflictPolicy decides what happens when an image is about to be saved under a name that already exists
+type conflictPolicy string
+
+const (
+	conflictSkip      conflictPolicy = "skip"      // Keep the existing file and don't download the image again
+	conflictOverwrite conflictPolicy = "overwrite" // Replace the existing file
+	conflictRename    conflictPolicy = "rename"    // Keep the existing file and save the image under a numbered name
+)
+
+// parseConflictPolicy validates the -on-conflict flag
+func parseConflictPolicy(value string) (conflictPolicy, error) {
+	switch policy := conflictPolicy(value); policy {
+	case conflictSkip, conflictOverwrite, conflictRename:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("invalid -on-conflict %q, expected skip, overwrite or rename", value)
+	}
+}
+
+// existingImage returns the path of an image saved earlier as name in the folder, or an empty string.
+// Without a forced extension the image may have been saved with any extension, so sidecars and
+// partial or invalid downloads are the only files with the name that are ignored.
+func existingImage(folder, name, extension string) string {
+	if extension != "" {
+		path := filepath.Join(folder, name+extension)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		return ""
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(folder, globEscape(name)+".*"))
+	for _, match := range matches {
+		switch filepath.Ext(match) {
+		case ".json", partSuffix, ".invalid", ".tmp":
+			continue
+		}
+		return match
+	}
+	return ""
+}
+
+// globEscape escapes the characters filepath.Glob treats as patterns
+func globEscape(name string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`)
+	return replacer.Replace(name)
+}
+
+// freeFileName returns the path to save name+extension under without replacing an existing file,
+// appending _1, _2 and so on to the name as needed
+func freeFileName(folder, name, extension string) string {
+	path := filepath.Join(folder, name+extension)
+	for i := 1; ; i++ {
+		if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+			return path
+		}
+		path = filepath.Join(folder, fmt.Sprintf("%s_%d%s", name, i, extension))
+	}
+}
+
+// savedImage describes an image file written to disk by downloadImage
+type savedImage struct {
+	Path        string
+	Extension   string // Extension to save the file with, from the detected type or -extension
+	ContentType string
+	Bytes       int64
+	SHA256      string // Hex-encoded SHA-256 of the file contents
+	StatusCode  int    // HTTP status of the first response the image was read from, also for resumed downloads
+}
+
+// rehash updates the size and checksum of the image to those of the file at its path, after embedProvenance
+// changed it. The dedupe index keeps the checksum of the downloaded bytes, so identical downloads still match.
+func (img *savedImage) rehash() error {
+	sum, err := fileSHA256(img.Path)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(img.Path)
+	if err != nil {
+		return err
+	}
+	img.SHA256, img.Bytes = sum, info.Size()
+	return nil
+}
+
+// partSuffix is appended to the names of images while they are being downloaded
+const partSuffix = ".part"
+
+// removeStaleParts deletes the .part files that interrupted runs left anywhere under the output directory
+func removeStaleParts(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.HasSuffix(path, partSuffix) {
+			return os.Remove(path)
+		}
+		return nil
+	})
+}
+
+// resumeAttempts is how many times an interrupted download is resumed with a Range request before giving up
+const resumeAttempts = 3
+
+// errTooLarge is returned for downloads bigger than the per-file size limit
+var errTooLarge = errors.New("file exceeds the maximum file size")
+
+// DownloadImage downloads the image from the given URL to partPath, a .part file that saveImage later renames,
+// so an interrupted run never leaves a truncated file under a final name.
+// The extension to save it with is taken from the file type detected in the first bytes of the download unless
+// opts.Extension forces one, and reportedType is the engine's guess used when detection fails. Downloads larger than
+// opts.MaxFileSize are aborted, checking Content-Length up front and the bytes received while streaming.
+// If the transfer breaks off and the server accepts byte ranges, it is resumed from the bytes already saved.
+// Every request carries the given headers. Cancelling ctx aborts the transfer and removes the partial file.
+func downloadImage(ctx context.Context, client *http.Client, url string, header http.Header, partPath, reportedType string, opts downloadOptions) (*savedImage, error) {
+	maxSize := opts.MaxFileSize
+	resp, err := requestImage(ctx, client, url, header, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download image: %w", err)
+	}
+	if maxSize > 0 && resp.ContentLength > maxSize {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: %d bytes", errTooLarge, resp.ContentLength)
+	}
+	resumable := resp.Header.Get("Accept-Ranges") == "bytes"
+	// The status of the first response is recorded, not the 206 of a resumed transfer
+	statusCode := resp.StatusCode
+
+	// Look at the first bytes to tell what the server actually sent
+	body := bufio.NewReaderSize(resp.Body, sniffLen)
+	head, _ := body.Peek(sniffLen)
+	contentType := detectContentType(head, resp.Header.Get("Content-Type"), reportedType)
+	extension := opts.Extension
+	if extension == "" {
+		extension = extensionFor(contentType)
+	}
+
+	out, err := os.Create(partPath)
+	if err != nil {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to create file: %v", err)
+	}
+
+	// Hash the image while it streams to disk
+	hash := sha256.New()
+	dst := io.MultiWriter(out, hash)
+
+	var src io.Reader = body
+	var written int64
+	for attempt := 1; ; attempt++ {
+		limited := src
+		if maxSize > 0 {
+			// Read one byte past the limit to tell a file of exactly maxSize bytes from a bigger one
+			limited = io.LimitReader(src, maxSize-written+1)
+		}
+		n, err := io.Copy(dst, limited)
+		resp.Body.Close()
+		written += n
+		if maxSize > 0 && written > maxSize {
+			out.Close()
+			os.Remove(partPath)
+			return nil, errTooLarge
+		}
+		if err == nil {
+			break
+		}
+		if !resumable || attempt > resumeAttempts || ctx.Err() != nil {
+			out.Close()
+			os.Remove(partPath)
+			return nil, fmt.Errorf("failed to save image: %v", err)
+		}
+
+		slog.Info("Resuming download", "url", url, "offset", written, "error", err)
+		resp, err = requestImage(ctx, client, url, header, written)
+		if err == nil && resp.StatusCode == http.StatusOK {
+			// The server sent the whole image again, so start over
+			written = 0
+			hash.Reset()
+			err = restartFile(out)
+		}
+		if err == nil {
+			src = resp.Body
+		}
+		if err != nil {
+			if resp != nil {
+				resp.Body.Close()
+			}
+			out.Close()
+			os.Remove(partPath)
+			return nil, fmt.Errorf("failed to resume image: %v", err)
+		}
+	}
+
+	if err := out.Close(); err != nil {
+		os.Remove(partPath)
+		return nil, fmt.Errorf("failed to save image: %v", err)
+	}
+
+	return &savedImage{Path: partPath, Extension: extension, ContentType: contentType, Bytes: written, SHA256: hex.EncodeToString(hash.Sum(nil)), StatusCode: statusCode}, nil
+}
+
+// writeCapturedImage writes an image captured from the browser to partPath like downloadImage, detecting its type
+// and hashing it the same way
+func writeCapturedImage(data []byte, partPath, reportedType string, opts downloadOptions) (*savedImage, error) {
+	size := int64(len(data))
+	if opts.MaxFileSize > 0 && size > opts.MaxFileSize {
+		return nil, fmt.Errorf("%w: %d bytes", errTooLarge, size)
+	}
+
+	contentType := detectContentType(data[:min(len(data), sniffLen)], reportedType, reportedType)
+	extension := opts.Extension
+	if extension == "" {
+		extension = extensionFor(contentType)
+	}
+	if err := os.WriteFile(partPath, data, 0o644); err != nil {
+		os.Remove(partPath)
+		return nil, fmt.Errorf("failed to save image: %v", err)
+	}
+
+	hash := sha256.Sum256(data)
+	return &savedImage{Path: partPath, Extension: extension, ContentType: contentType, Bytes: size, SHA256: hex.EncodeToString(hash[:]), StatusCode: http.StatusOK}, nil
+}
+
+// errExists is returned by saveImage when the -on-conflict policy keeps an existing file
+var errExists = errors.New("file already exists")
+
+// imageDestination returns the path to save a downloaded image at, name plus its extension in the folder.
+// An existing file with that name is overwritten, kept with the image saved under a numbered name,
+// or kept with the download discarded with errExists, depending on the conflict policy.
+func imageDestination(img *savedImage, folder, name string, policy conflictPolicy) (string, error) {
+	path := filepath.Join(folder, name+img.Extension)
+	switch policy {
+	case conflictRename:
+		path = freeFileName(folder, name, img.Extension)
+	case conflictSkip:
+		if _, err := os.Stat(path); err == nil {
+			os.Remove(img.Path)
+			return "", fmt.Errorf("%w: %s", errExists, path)
+		}
+	}
+	return path, nil
+}
+
+// moveImage moves a downloaded image from its .part file to path, so an interrupted run never leaves a
+// truncated file under a final name
+func moveImage(img *savedImage, path string) error {
+	if err := os.Rename(img.Path, path); err != nil {
+		os.Remove(img.Path)
+		return fmt.Errorf("failed to save image: %v", err)
+	}
+	img.Path = path
+	return nil
+}
+
+// imageHeader returns the headers to download a result with. Hotlink-protected hosts often refuse requests
+// without a browser User-Agent or a Referer, so those default to a Chrome UA and the result's source page.
+func imageHeader(result searcher.Result, extra http.Header) http.Header {
+	header := http.Header{"User-Agent": {searcher.UserAgent}}
+	if result.PageURL != "" {
+		header.Set("Referer", result.PageURL)
+	}
+	for name, values := range extra {
+		header.Del(name)
+		for _, value := range values {
+			if value != "" {
+				header.Add(name, value)
+			}
+		}
+	}
+	return header
+}
+
+// statusError is returned for an image request the server answered with a status other than 2xx
+type statusError struct {
+	code   int
+	status string
+}
+
+func (e *statusError) Error() string {
+	return "server answered " + e.status
+}
+
+// requestImage requests the image, from byte offset onwards if offset is positive.
+// A request fails unless the server answers with a 2xx status, and a ranged one unless it answers with the
+// requested range or the whole image.
+func requestImage(ctx context.Context, client *http.Client, url string, header http.Header, offset int64) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = header.Clone()
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if offset == 0 && (resp.StatusCode < 200 || resp.StatusCode > 299) {
+		resp.Body.Close()
+		return nil, &statusError{code: resp.StatusCode, status: resp.Status}
+	}
+	if offset > 0 && resp.StatusCode != http.StatusOK &&
+		(resp.StatusCode != http.StatusPartialContent || !strings.HasPrefix(resp.Header.Get("Content-Range"), fmt.Sprintf("bytes %d-", offset))) {
+		resp.Body.Close()
+		return nil, fmt.Errorf("server did not resume at byte %d: %s", offset, resp.Status)
+	}
+	return resp, nil
+}
+
+// restartFile empties the file so a download can be written again from the start
+func restartFile(f *os.File) error {
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+	_, err := f.Seek(0, io.SeekStart)
+	return err
+}
+
+// imageSidecar is the metadata written next to each saved image when -sidecars is set
+type imageSidecar struct {
+	SourceURL    string    `json:"source_url"`
+	PageURL      string    `json:"page_url"`
+	Engine       string    `json:"engine"`
+	Query        string    `json:"query"`
+	Title        string    `json:"title,omitempty"`
+	Description  string    `json:"description,omitempty"`
+	License      string    `json:"license,omitempty"`
+	LicenseURL   string    `json:"license_url,omitempty"`
+	Author       string    `json:"author,omitempty"`
+	Width        int       `json:"width,omitempty"`
+	Height       int       `json:"height,omitempty"`
+	ContentType  string    `json:"content_type"`
+	Bytes        int64     `json:"bytes"`
+	SHA256       string    `json:"sha256"`
+	ScrapedAt    time.Time `json:"scraped_at"`
+	DownloadedAt time.Time `json:"downloaded_at"`
+}
+
+// WriteSidecar writes the metadata as <name>.json next to the saved image.
+// The file is written to a temporary name first and renamed so readers never see a partial sidecar.
+func writeSidecar(img *savedImage, sidecar imageSidecar) error {
+	if width, height := imageDimensions(img.Path, img.ContentType); width > 0 {
+		sidecar.Width, sidecar.Height = width, height
+	}
+
+	data, err := json.MarshalIndent(sidecar, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode sidecar: %v", err)
+	}
+
+	sidecarPath := strings.TrimSuffix(img.Path, filepath.Ext(img.Path)) + ".json"
+	tmpPath := sidecarPath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write sidecar: %v", err)
+	}
+	if err := os.Rename(tmpPath, sidecarPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write sidecar: %v", err)
+	}
+
+	return nil
+}
+
+// downloadOptions holds the settings shared by every download of a run
+type downloadOptions struct {
+	Workers   int    // Number of downloads in flight at once
+	Sidecars  bool   // Writes a <name>.json metadata file next to each saved image
+	Embed     bool   // Writes the provenance of each saved image into its XMP metadata
+	Extension string // Extension given to every saved file, empty to use the one of the detected file type
+
+	NameTemplate nameTemplate // Builds the names files are saved under
+
+	// Headers are added to every download, replacing the default User-Agent and Referer.
+	// A header with an empty value removes the default.
+	Headers http.Header
+
+	Proxies *proxyPool // Pool the client was created with, each download picks its proxy from it; nil if not used
+
+	MinWidth     int    // Images narrower than this many pixels are skipped or discarded, 0 for any
+	MinHeight    int    // Images lower than this many pixels are skipped or discarded, 0 for any
+	MinPixels    int    // Images with fewer pixels in all, from a -size in megapixels, are skipped or discarded, 0 for any
+	Aspect       string // Images without this aspect ratio are skipped or discarded, empty for any
+	MaxFileSize  int64  // Downloads larger than this many bytes are aborted, 0 for no limit
+	MaxTotalSize int64  // The run stops once the saved files add up to this many bytes, 0 for no limit
+
+	// Formats holds the content types to keep, files of other types are skipped or discarded; nil for any
+	Formats map[string]bool
+
+	OnConflict conflictPolicy // What to do when an image with the same name was saved before
+
+	DedupeContent bool         // Drops images whose contents are identical to an image saved earlier in the run
+	DedupeStore   *dedupeStore // Database of images from earlier runs to skip and drop, nil if not used
+
+	// EngineOrder ranks the engines by -prefer-engine, so among identical images of the run the one of the first
+	// engine is kept whichever download finishes first
+	EngineOrder []string
+
+	// KeepInvalid keeps files that fail validation, renamed with an .invalid suffix, instead of deleting them
+	KeepInvalid bool
+
+	HeadConcurrency int // Number of HEAD requests in flight at once when checking images before downloading them
+
+	Robots *robotsCache // Skips images the robots.txt of their host disallows, nil to ignore robots.txt
+
+	Progress *progress // Shows the progress of the downloads
+
+	// Shared keeps the dedupe index and the saved size across downloadImages calls, so a run downloading
+	// in several rounds dedupes and stops at MaxTotalSize as a whole; nil for a single round
+	Shared *downloadState
+
+	// Report, if set, is called with the outcome of every job, from several goroutines at once
+	Report func(downloadOutcome)
+}
+
+// downloadState is what successive downloadImages calls of a run share
+type downloadState struct {
+	index *contentIndex
+	total atomic.Int64
+}
+
+// newDownloadState returns the state shared by the rounds of a run, remembering images in store if set and keeping
+// the images of the engines earlier in order over identical ones
+func newDownloadState(store *dedupeStore, order []string) *downloadState {
+	return &downloadState{index: newContentIndex(store, order)}
+}
+
+// downloadJob is a single result to download and the folder to save it in
+type downloadJob struct {
+	Result searcher.Result
+	Folder string
+	Index  int // 1-based position of the result within its target, used in the file name
+
+	ScrapedAt time.Time // When the search returned the result
+
+	Quota *labelQuota // Target of the job's -dataset label, nil if it has none
+}
+
+// DownloadImages downloads the jobs with a fixed pool of workers shared by all targets, so large result sets
+// never open more than that many connections at once. Once ctx is cancelled no new downloads are started
+// and the ones in flight are aborted. Every download waits for the limiter of its host before starting.
+// Reaching opts.MaxTotalSize cancels the remaining downloads the same way.
+// It returns the images dropped as exact duplicates when opts.DedupeContent or opts.DedupeStore is set.
+func downloadImages(ctx context.Context, client *http.Client, limiter *hostLimiter, jobs []downloadJob, opts downloadOptions) []duplicateImage {
+	state := opts.Shared
+	if state == nil {
+		state = newDownloadState(opts.DedupeStore, opts.EngineOrder)
+	}
+	index, total := state.index, &state.total
+	if opts.MaxTotalSize > 0 && total.Load() >= opts.MaxTotalSize {
+		return index.duplicates
+	}
+	bar := opts.Progress.stage("Downloading images", "failed", len(jobs))
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var quotaReached sync.Once
+
+	queue := make(chan downloadJob)
+	var wg sync.WaitGroup
+	for w := 0; w < min(max(opts.Workers, 1), len(jobs)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range queue {
+				var outcome downloadOutcome
+				if job.Quota.acquire(ctx) {
+					outcome = downloadJobImage(ctx, client, limiter, index, job, opts)
+					job.Quota.release(outcome.Status == statusSaved)
+				} else {
+					outcome = jobOutcome(job, statusSkipped, "label has enough images")
+				}
+				bar.add(outcome.Status == statusFailed, outcome.Bytes)
+				if opts.Report != nil {
+					opts.Report(outcome)
+				}
+				if opts.MaxTotalSize > 0 && total.Add(outcome.Bytes) >= opts.MaxTotalSize {
+					quotaReached.Do(func() {
+						slog.Info("Reached the total size limit, stopping downloads", "bytes", opts.MaxTotalSize)
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+
+feed:
+	for _, job := range jobs {
+		select {
+		case queue <- job:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(queue)
+
+	// Wait for the downloads in flight to complete
+	wg.Wait()
+	bar.finish()
+	return index.duplicates
+}
+
+// downloadStatus is how a download job ended
+type downloadStatus string
+
+const (
+	statusSaved     downloadStatus = "saved"     // The image was saved
+	statusSkipped   downloadStatus = "skipped"   // The image was not downloaded, e.g. because it exists already
+	statusFailed    downloadStatus = "failed"    // The download or saving failed
+	statusInvalid   downloadStatus = "invalid"   // The download was not a valid image or too small
+	statusDuplicate downloadStatus = "duplicate" // The image was identical to one saved before
+)
+
+// downloadOutcome reports how a single job ended, for -output-format and the manifest
+type downloadOutcome struct {
+	Job        downloadJob
+	Status     downloadStatus
+	Reason     string // Why the image was not saved, empty if it was
+	Path       string // Where the image was saved, empty if it wasn't
+	Bytes      int64  // Size of the saved image, or of the removed one if Replaced is set
+	Width      int    // Dimensions read from the saved image, 0 if unknown
+	Height     int
+	StatusCode int       // HTTP status of the download, 0 if no response was read
+	SHA256     string    // Hex-encoded SHA-256 of the downloaded file, empty if nothing was downloaded
+	Time       time.Time // When the job ended
+
+	// Replaced is the path of an image the job was reported to have saved earlier, which was removed since for
+	// an identical image of a preferred engine. The outcome then corrects the earlier one.
+	Replaced string
+}
+
+// jobOutcome returns the outcome of a job that ended without saving an image
+func jobOutcome(job downloadJob, status downloadStatus, reason string) downloadOutcome {
+	return downloadOutcome{Job: job, Status: status, Reason: reason, Time: time.Now().UTC()}
+}
+
+// withImage adds the response status and checksum of the downloaded image to the outcome
+func (o downloadOutcome) withImage(img *savedImage) downloadOutcome {
+	o.StatusCode = img.StatusCode
+	o.SHA256 = img.SHA256
+	return o
+}
+
+// downloadJobImage downloads a single job and writes its sidecar if requested
+func downloadJobImage(ctx context.Context, client *http.Client, limiter *hostLimiter, index *contentIndex, job downloadJob, opts downloadOptions) downloadOutcome {
+	result := job.Result
+	// Skip undersized images up front when the engine reported their dimensions
+	if (result.Width > 0 && result.Width < opts.MinWidth) || (result.Height > 0 && result.Height < opts.MinHeight) ||
+		(result.Width > 0 && result.Height > 0 && result.Width*result.Height < opts.MinPixels) {
+		slog.Debug("Skipping image below the minimum resolution", "engine", result.Engine, "index", job.Index, "url", result.URL, "width", result.Width, "height", result.Height)
+		return jobOutcome(job, statusSkipped, fmt.Sprintf("%dx%d is below the minimum resolution", result.Width, result.Height))
+	}
+	if !searcher.MatchesAspect(opts.Aspect, result.Width, result.Height) {
+		slog.Debug("Skipping image with the wrong aspect ratio", "engine", result.Engine, "index", job.Index, "url", result.URL, "width", result.Width, "height", result.Height)
+		return jobOutcome(job, statusSkipped, fmt.Sprintf("%dx%d is not %s", result.Width, result.Height, opts.Aspect))
+	}
+
+	if opts.Formats != nil && typeExtensions[result.ContentType] != "" && !opts.Formats[result.ContentType] {
+		slog.Debug("Skipping image in a format that isn't allowed", "engine", result.Engine, "index", job.Index, "url", result.URL, "content_type", result.ContentType)
+		return jobOutcome(job, statusSkipped, result.ContentType+" is not one of the allowed formats")
+	}
+
+	fields := nameFields{Query: result.Query, Engine: result.Engine, Index: job.Index, URL: result.URL}
+	if opts.OnConflict == conflictSkip && !opts.NameTemplate.needsContent {
+		if existing := existingImage(job.Folder, opts.NameTemplate.render(fields), opts.Extension); existing != "" {
+			slog.Debug("Skipping image that already exists", "engine", result.Engine, "index", job.Index, "url", result.URL, "path", existing)
+			return jobOutcome(job, statusSkipped, existing+" already exists")
+		}
+	}
+
+	if opts.DedupeStore != nil {
+		if seen, err := opts.DedupeStore.hasURL(result.URL); err != nil {
+			slog.Error("Failed to look up URL in the dedupe database", "url", result.URL, "error", err)
+		} else if seen {
+			slog.Debug("Skipping image downloaded in an earlier run", "engine", result.Engine, "index", job.Index, "url", result.URL)
+			return jobOutcome(job, statusSkipped, "downloaded in an earlier run")
+		}
+	}
+
+	// The query and index keep the .part file unique within the folder
+	partPath := filepath.Join(job.Folder, fmt.Sprintf("%s%d%s", unsafeNameChars.ReplaceAllString(result.Query, "_"), job.Index, partSuffix))
+	var img *savedImage
+	var err error
+	if result.Data != nil {
+		// The image was captured from the browser, so there is nothing to download
+		img, err = writeCapturedImage(result.Data, partPath, result.ContentType, opts)
+	} else {
+		var skipped string
+		if img, skipped, err = fetchJobImage(ctx, client, limiter, job, partPath, opts); skipped != "" {
+			return jobOutcome(job, statusSkipped, skipped)
+		}
+	}
+	if err != nil {
+		slog.Warn("Failed to download image", "engine", result.Engine, "index", job.Index, "url", result.URL, "error", err)
+		failed := jobOutcome(job, statusFailed, err.Error())
+		var statusErr *statusError
+		if errors.As(err, &statusErr) {
+			failed.StatusCode = statusErr.code
+		}
+		return failed
+	}
+
+	fields.SHA256 = img.SHA256
+	fields.Width, fields.Height = imageDimensions(img.Path, img.ContentType)
+	name := opts.NameTemplate.render(fields)
+
+	if err := validateImage(img, opts); err != nil {
+		slog.Info("Discarding invalid image", "engine", result.Engine, "index", job.Index, "url", result.URL, "reason", err)
+		reason := err.Error()
+		if opts.KeepInvalid {
+			err = os.Rename(img.Path, filepath.Join(job.Folder, name+img.Extension+".invalid"))
+		} else {
+			err = os.Remove(img.Path)
+		}
+		if err != nil {
+			slog.Error("Failed to discard image", "engine", result.Engine, "index", job.Index, "error", err)
+		}
+		return jobOutcome(job, statusInvalid, reason).withImage(img)
+	}
+
+	// Duplicates are found before the image is moved into place, so an image replacing its own earlier copy
+	// under the same name is never mistaken for one and deleted
+	var claim contentClaim
+	path, err := imageDestination(img, job.Folder, name, opts.OnConflict)
+	if err == nil {
+		if opts.DedupeContent || opts.DedupeStore != nil {
+			if claim, err = index.save(img, job, path); err == nil && claim.duplicateOf != "" {
+				slog.Debug("Dropping duplicate image", "engine", result.Engine, "index", job.Index, "url", result.URL, "original", claim.duplicateOf)
+				return jobOutcome(job, statusDuplicate, "identical to "+claim.duplicateOf).withImage(img)
+			}
+		} else {
+			err = moveImage(img, path)
+		}
+	}
+	if err != nil {
+		if errors.Is(err, errExists) {
+			slog.Debug("Skipping image that already exists", "engine", result.Engine, "index", job.Index, "url", result.URL, "reason", err)
+			return jobOutcome(job, statusSkipped, err.Error()).withImage(img)
+		}
+		slog.Error("Failed to save image", "engine", result.Engine, "index", job.Index, "url", result.URL, "error", err)
+		return jobOutcome(job, statusFailed, err.Error()).withImage(img)
+	}
+
+	if opts.Embed {
+		if err := embedProvenance(img.Path, img.ContentType, result); err != nil {
+			slog.Error("Failed to embed metadata", "engine", result.Engine, "index", job.Index, "path", img.Path, "error", err)
+		} else if err := img.rehash(); err != nil {
+			slog.Error("Failed to checksum image with embedded metadata", "path", img.Path, "error", err)
+		}
+	}
+	if opts.Sidecars {
+		writeJobSidecar(job, img)
+	}
+	if claim.image != nil {
+		if replacedBy := index.settle(claim.image, img); replacedBy != "" {
+			// An identical image of a preferred engine was saved while this one was finishing
+			slog.Debug("Dropping image replaced by an identical one of a preferred engine", "engine", result.Engine, "index", job.Index, "url", result.URL, "original", replacedBy)
+			removeImage(img.Path, opts)
+			return jobOutcome(job, statusDuplicate, "identical to "+replacedBy).withImage(img)
+		}
+	}
+	if replaced := claim.replaced; replaced != nil {
+		slog.Debug("Replacing image of a less preferred engine", "engine", replaced.job.Result.Engine, "path", replaced.path, "original", img.Path)
+		removeImage(replaced.path, opts)
+		replaced.job.Quota.remove()
+		if opts.Report != nil {
+			corrected := jobOutcome(replaced.job, statusDuplicate, "identical to "+img.Path)
+			corrected.Bytes, corrected.SHA256, corrected.Replaced = replaced.bytes, replaced.sha256, replaced.path
+			opts.Report(corrected)
+		}
+	}
+	slog.Debug("Saved image", "engine", result.Engine, "index", job.Index, "url", result.URL, "path", img.Path, "bytes", img.Bytes)
+	saved := downloadOutcome{Job: job, Status: statusSaved, Path: img.Path, Bytes: img.Bytes, Width: fields.Width, Height: fields.Height, Time: time.Now().UTC()}
+	return saved.withImage(img)
+}
+
+// fetchJobImage downloads the image of a job to partPath through its host limiter, proxy and robots.txt check.
+// It returns the reason if the image was skipped without requesting it.
+func fetchJobImage(ctx context.Context, client *http.Client, limiter *hostLimiter, job downloadJob, partPath string, opts downloadOptions) (*savedImage, string, error) {
+	result := job.Result
+	release, err := limiter.wait(ctx, result.URL)
+	if err != nil {
+		return nil, err.Error(), nil
+	}
+	defer release()
+
+	var proxy *url.URL
+	if opts.Proxies != nil {
+		proxy = opts.Proxies.pick(result.Engine)
+		ctx = withProxy(ctx, proxy)
+	}
+	if opts.Robots != nil && !opts.Robots.allowed(ctx, result.URL) {
+		if ctx.Err() == nil {
+			slog.Debug("Skipping image disallowed by robots.txt", "engine", result.Engine, "index", job.Index, "url", result.URL)
+		}
+		return nil, "disallowed by robots.txt", nil
+	}
+	img, err := downloadImage(ctx, client, result.URL, imageHeader(result, opts.Headers), partPath, result.ContentType, opts)
+	if opts.Proxies != nil && ctx.Err() == nil {
+		// Only connection failures count against the proxy, not the image host's answers
+		var netErr net.Error
+		opts.Proxies.report(proxy, !errors.As(err, &netErr))
+	}
+	return img, "", err
+}
+
+// removeImage removes a saved image that turned out to be a duplicate, along with its sidecar
+func removeImage(path string, opts downloadOptions) {
+	if err := os.Remove(path); err != nil {
+		slog.Error("Failed to remove duplicate image", "path", path, "error", err)
+	}
+	if opts.Sidecars {
+		os.Remove(strings.TrimSuffix(path, filepath.Ext(path)) + ".json")
+	}
+}
+
+// writeJobSidecar writes the sidecar of a saved job, logging failures
+func writeJobSidecar(job downloadJob, img *savedImage) {
+	result := job.Result
+	err := writeSidecar(img, imageSidecar{
+		SourceURL:    result.URL,
+		PageURL:      result.PageURL,
+		Engine:       result.Engine,
+		Query:        result.Query,
+		Title:        result.Title,
+		Description:  result.Description,
+		License:      result.License,
+		LicenseURL:   result.LicenseURL,
+		Author:       result.Author,
+		Width:        result.Width,
+		Height:       result.Height,
+		ContentType:  img.ContentType,
+		Bytes:        img.Bytes,
+		SHA256:       img.SHA256,
+		ScrapedAt:    job.ScrapedAt,
+		DownloadedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		slog.Error("Failed to write sidecar", "engine", result.Engine, "index", job.Index, "path", img.Path, "error", err)
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image/color"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestDownloadImageRejectsErrorStatus(t *testing.T) {
+	for _, code := range []int{http.StatusNotFound, http.StatusForbidden, http.StatusInternalServerError} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "image/jpeg")
+			w.WriteHeader(code)
+			w.Write([]byte("\xff\xd8\xff not really an image"))
+		}))
+
+		partPath := filepath.Join(t.TempDir(), "image.part")
+		img, err := downloadImage(context.Background(), server.Client(), server.URL, http.Header{}, partPath, "", downloadOptions{})
+		server.Close()
+
+		var statusErr *statusError
+		if !errors.As(err, &statusErr) || statusErr.code != code {
+			t.Errorf("status %d: got image %v and error %v, want a statusError", code, img, err)
+		}
+		if _, err := os.Stat(partPath); !os.IsNotExist(err) {
+			t.Errorf("status %d: the response was written to %s", code, partPath)
+		}
+	}
+}
+
+func TestDownloadImageSavesSuccess(t *testing.T) {
+	body := []byte("\x89PNG\r\n\x1a\n rest of the image")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	partPath := filepath.Join(t.TempDir(), "image.part")
+	img, err := downloadImage(context.Background(), server.Client(), server.URL, http.Header{}, partPath, "", downloadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.StatusCode != http.StatusOK || img.Bytes != int64(len(body)) || img.Extension != ".png" {
+		t.Errorf("got status %d, %d bytes and extension %s", img.StatusCode, img.Bytes, img.Extension)
+	}
+}
+
+func TestDownloadJobImageRecordsEmbeddedChecksum(t *testing.T) {
+	folder := t.TempDir()
+	template, err := parseNameTemplate("{engine}{ext}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := openManifest(filepath.Join(folder, "manifest.csv"), folder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer manifest.Close()
+
+	data := testPNG(t, color.White)
+	opts := downloadOptions{NameTemplate: template, OnConflict: conflictOverwrite, Embed: true}
+	outcome := downloadJobImage(context.Background(), nil, nil, newContentIndex(nil, nil), capturedJob(folder, "bing", 1, data), opts)
+	if outcome.Status != statusSaved {
+		t.Fatalf("got %s: %s", outcome.Status, outcome.Reason)
+	}
+	if err := manifest.write(outcome); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := os.ReadFile(outcome.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(file) == len(data) {
+		t.Fatal("no metadata was embedded")
+	}
+	sum := sha256.Sum256(file)
+	recorded, err := os.Open(filepath.Join(folder, "manifest.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer recorded.Close()
+	rows, err := csv.NewReader(recorded).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	row := rows[len(rows)-1]
+	if row[6] != strconv.Itoa(len(file)) || row[7] != hex.EncodeToString(sum[:]) {
+		t.Errorf("manifest records %s bytes with SHA-256 %s, the file has %d bytes with %x", row[6], row[7], len(file), sum)
+	}
+}
+
+func TestDownloadImageResumeRecordsFirstStatus(t *testing.T) {
+	body := []byte("\x89PNG\r\n\x1a\n rest of the image")
+	half := len(body) / 2
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Accept-Ranges", "bytes")
+		if r.Header.Get("Range") == "" {
+			// Break off halfway through the image
+			w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+			w.Write(body[:half])
+			return
+		}
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", half, len(body)-1, len(body)))
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write(body[half:])
+	}))
+	defer server.Close()
+
+	partPath := filepath.Join(t.TempDir(), "image.part")
+	img, err := downloadImage(context.Background(), server.Client(), server.URL, http.Header{}, partPath, "", downloadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.StatusCode != http.StatusOK || img.Bytes != int64(len(body)) {
+		t.Errorf("got status %d and %d bytes, want 200 and %d", img.StatusCode, img.Bytes, len(body))
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"text/tabwriter"
+)
+
+// dryRun prints the jobs that would be downloaded with their sizes as reported by HEAD requests, then the totals
+// per engine, without downloading or writing anything. Sizes the server doesn't report are shown as unknown.
+func dryRun(ctx context.Context, w io.Writer, client *http.Client, limiter *hostLimiter, jobs []downloadJob, opts downloadOptions) error {
+	bar := opts.Progress.stage("Estimating sizes", "unknown", len(jobs))
+	sizes := make([]int64, len(jobs))
+	forEachJob(ctx, len(jobs), opts.HeadConcurrency, func(i int) {
+		sizes[i] = headSize(ctx, client, limiter, jobs[i], opts)
+		bar.add(sizes[i] < 0, 0)
+	})
+	bar.finish()
+
+	type engineTotal struct {
+		images, unknown int
+		bytes           int64
+	}
+	var engines []string
+	totals := make(map[string]*engineTotal)
+	var all engineTotal
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Engine\tSize\tURL")
+	for i, job := range jobs {
+		engine := job.Result.Engine
+		total, ok := totals[engine]
+		if !ok {
+			total = &engineTotal{}
+			totals[engine] = total
+			engines = append(engines, engine)
+		}
+		for _, t := range []*engineTotal{total, &all} {
+			t.images++
+			if sizes[i] < 0 {
+				t.unknown++
+			} else {
+				t.bytes += sizes[i]
+			}
+		}
+
+		size := "unknown"
+		if sizes[i] >= 0 {
+			size = formatSize(float64(sizes[i]))
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", engine, size, job.Result.URL)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w)
+	for _, engine := range engines {
+		t := totals[engine]
+		fmt.Fprintf(w, "%s: %d images, %s (%d of unknown size)\n", engine, t.images, formatSize(float64(t.bytes)), t.unknown)
+	}
+	_, err := fmt.Fprintf(w, "Would download %d images from %d engines, %s (%d of unknown size)\n", all.images, len(engines), formatSize(float64(all.bytes)), all.unknown)
+	return err
+}
+
+// headSize returns the size of the job's image from the Content-Length of a HEAD request, or -1 if the
+// server doesn't report it or the request fails. Images captured from the browser have their size at hand.
+func headSize(ctx context.Context, client *http.Client, limiter *hostLimiter, job downloadJob, opts downloadOptions) int64 {
+	if job.Result.Data != nil {
+		return int64(len(job.Result.Data))
+	}
+	release, err := limiter.wait(ctx, job.Result.URL)
+	if err != nil {
+		return -1
+	}
+	defer release()
+
+	if opts.Proxies != nil {
+		ctx = withProxy(ctx, opts.Proxies.pick(job.Result.Engine))
+	}
+	resp, err := headRequest(ctx, client, job, opts)
+	if err != nil || resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return -1
+	}
+	return resp.ContentLength
+}
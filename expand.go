@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/selman92/image-searcher/pkg/searcher"
+)
+
+// datamuseURL is the word-finding API synonyms are looked up with
+const datamuseURL = "https://api.datamuse.com/words"
+
+// expandOptions selects the query variants of -expand and -expand-file
+type expandOptions struct {
+	Inflect     bool                // Adds the plural or singular form of the query's last word
+	MaxSynonyms int                 // Adds up to this many synonyms of the query from the Datamuse API, 0 for none
+	Expansions  map[string][]string // Variants of terms from the expansion file, nil for none
+}
+
+// expandQuery returns the query followed by its variants, without duplicates. Variants that are not valid
+// queries are left out, and a failed synonym lookup is logged without stopping the search.
+func expandQuery(ctx context.Context, query string, opts expandOptions) []string {
+	variants := expandTerms(query, opts.Expansions)
+	if opts.Inflect {
+		if inflected := inflectLastWord(query); inflected != "" {
+			variants = append(variants, inflected)
+		}
+	}
+	if opts.MaxSynonyms > 0 {
+		synonyms, err := lookupSynonyms(ctx, query, opts.MaxSynonyms)
+		if err != nil {
+			slog.Warn("Failed to look up synonyms", "query", query, "error", err)
+		}
+		variants = append(variants, synonyms...)
+	}
+
+	queries := []string{query}
+	for _, variant := range variants {
+		variant, err := searcher.ParseQuery(variant)
+		if err != nil || containsFold(queries, variant) {
+			continue
+		}
+		queries = append(queries, variant)
+	}
+	if len(queries) > 1 {
+		slog.Info("Expanded query", "query", query, "variants", queries[1:])
+	}
+	return queries
+}
+
+// loadExpansions reads an expansion file. Every line maps a term to comma-separated variants, like
+// "cat: kitten, feline"; blank lines and lines starting with # are ignored.
+func loadExpansions(path string) (map[string][]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read expansion file: %v", err)
+	}
+	defer f.Close()
+
+	expansions := make(map[string][]string)
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		term, list, ok := strings.Cut(text, ":")
+		term = strings.ToLower(strings.TrimSpace(term))
+		if !ok || term == "" {
+			return nil, fmt.Errorf("%s:%d: expected \"term: variant, variant\"", path, line)
+		}
+		for _, variant := range strings.Split(list, ",") {
+			if variant = strings.TrimSpace(variant); variant != "" {
+				expansions[term] = append(expansions[term], variant)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read expansion file: %v", err)
+	}
+	return expansions, nil
+}
+
+// expandTerms returns a variant of the query for every variant of every expansion term it contains as whole words
+func expandTerms(query string, expansions map[string][]string) []string {
+	var variants []string
+	for term, replacements := range expansions {
+		pattern := regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(term) + `\b`)
+		if !pattern.MatchString(query) {
+			continue
+		}
+		for _, replacement := range replacements {
+			variants = append(variants, pattern.ReplaceAllLiteralString(query, replacement))
+		}
+	}
+	return variants
+}
+
+// wordPattern matches the plain English words inflectLastWord knows how to inflect
+var wordPattern = regexp.MustCompile(`^[a-z]{3,}$`)
+
+// inflectLastWord returns the query with its last word turned from singular to plural or back, using the
+// regular English rules, or an empty string if the last word is not a plain lower case word
+func inflectLastWord(query string) string {
+	head, word := "", query
+	if i := strings.LastIndexByte(query, ' '); i >= 0 {
+		head, word = query[:i+1], query[i+1:]
+	}
+	if !wordPattern.MatchString(word) {
+		return ""
+	}
+
+	var inflected string
+	switch {
+	case strings.HasSuffix(word, "ies"):
+		inflected = strings.TrimSuffix(word, "ies") + "y"
+	case strings.HasSuffix(word, "ses"), strings.HasSuffix(word, "xes"), strings.HasSuffix(word, "zes"),
+		strings.HasSuffix(word, "ches"), strings.HasSuffix(word, "shes"):
+		inflected = strings.TrimSuffix(word, "es")
+	case strings.HasSuffix(word, "ss"), strings.HasSuffix(word, "us"), strings.HasSuffix(word, "x"), strings.HasSuffix(word, "z"),
+		strings.HasSuffix(word, "ch"), strings.HasSuffix(word, "sh"):
+		inflected = word + "es"
+	case strings.HasSuffix(word, "s"):
+		inflected = strings.TrimSuffix(word, "s")
+	case strings.HasSuffix(word, "y") && !strings.ContainsRune("aeiou", rune(word[len(word)-2])):
+		inflected = strings.TrimSuffix(word, "y") + "ies"
+	default:
+		inflected = word + "s"
+	}
+	return head + inflected
+}
+
+// datamuseWord is a word returned by the Datamuse API
+type datamuseWord struct {
+	Word string `json:"word"`
+}
+
+// lookupSynonyms returns up to max synonyms of the query from the Datamuse API
+func lookupSynonyms(ctx context.Context, query string, max int) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	endpoint := datamuseURL + "?" + url.Values{"rel_syn": {query}, "max": {strconv.Itoa(max)}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", searcher.UserAgent)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s from %s", resp.Status, req.URL.Host)
+	}
+
+	var words []datamuseWord
+	if err := json.NewDecoder(resp.Body).Decode(&words); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %v", err)
+	}
+	synonyms := make([]string, 0, len(words))
+	for _, w := range words {
+		synonyms = append(synonyms, w.Word)
+	}
+	return synonyms, nil
+}
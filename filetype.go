@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"log/slog"
+	"mime"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/selman92/image-searcher/pkg/searcher"
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
+)
+
+// sniffLen is how many leading bytes of a download are inspected to detect its type
+const sniffLen = 512
+
+// typeExtensions maps the content types of downloads to the extension they are saved with
+var typeExtensions = map[string]string{
+	"image/jpeg":    ".jpg",
+	"image/png":     ".png",
+	"image/gif":     ".gif",
+	"image/webp":    ".webp",
+	"image/bmp":     ".bmp",
+	"image/x-icon":  ".ico",
+	"image/avif":    ".avif",
+	"image/heic":    ".heic",
+	"image/tiff":    ".tif",
+	"image/svg+xml": ".svg",
+	"video/mp4":     ".mp4",
+	"video/webm":    ".webm",
+	"text/html":     ".html",
+}
+
+// formatTypes maps the format names of searcher.ParseFormats to their content types
+var formatTypes = map[string]string{
+	"jpg":  "image/jpeg",
+	"png":  "image/png",
+	"gif":  "image/gif",
+	"webp": "image/webp",
+	"svg":  "image/svg+xml",
+	"bmp":  "image/bmp",
+	"ico":  "image/x-icon",
+	"avif": "image/avif",
+	"heic": "image/heic",
+	"tiff": "image/tiff",
+}
+
+// formatContentTypes returns the set of content types of the formats, nil if there are none
+func formatContentTypes(formats []string) map[string]bool {
+	if len(formats) == 0 {
+		return nil
+	}
+	contentTypes := make(map[string]bool)
+	for _, format := range formats {
+		contentTypes[formatTypes[format]] = true
+	}
+	return contentTypes
+}
+
+// defaultExtension is used when neither the content nor the server reveal a known type
+const defaultExtension = ".jpg"
+
+// detectContentType returns the type of a download from its first bytes, falling back to the Content-Type
+// header sent by the server and then to the type the search engine reported.
+// Magic bytes win because many servers label every file image/jpeg, or serve HTML error pages with a 200.
+func detectContentType(head []byte, header, reported string) string {
+	if contentType := sniffContentType(head); contentType != "" {
+		return contentType
+	}
+	for _, contentType := range []string{header, reported} {
+		if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+			if _, ok := typeExtensions[mediaType]; ok {
+				return mediaType
+			}
+		}
+	}
+	return ""
+}
+
+// sniffContentType recognises the types in typeExtensions by their magic bytes, or returns an empty string
+func sniffContentType(head []byte) string {
+	// ISO base media files carry their brand in the ftyp box, which http.DetectContentType only checks for MP4
+	if len(head) >= 12 && string(head[4:8]) == "ftyp" {
+		switch string(head[8:12]) {
+		case "avif", "avis":
+			return "image/avif"
+		case "heic", "heix", "mif1", "msf1":
+			return "image/heic"
+		}
+	}
+	if bytes.HasPrefix(head, []byte("II*\x00")) || bytes.HasPrefix(head, []byte("MM\x00*")) {
+		return "image/tiff"
+	}
+
+	trimmed := bytes.TrimSpace(head)
+	if bytes.HasPrefix(trimmed, []byte("<svg")) || (bytes.HasPrefix(trimmed, []byte("<?xml")) && bytes.Contains(head, []byte("<svg"))) {
+		return "image/svg+xml"
+	}
+
+	contentType, _, _ := strings.Cut(http.DetectContentType(head), ";")
+	if _, ok := typeExtensions[contentType]; ok {
+		return contentType
+	}
+	return ""
+}
+
+// extensionFor returns the extension to save a file of the content type with
+func extensionFor(contentType string) string {
+	if extension, ok := typeExtensions[contentType]; ok {
+		return extension
+	}
+	return defaultExtension
+}
+
+// decodableTypes are the content types whose headers the image package can decode with the registered formats
+var decodableTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+	"image/bmp":  true,
+	"image/tiff": true,
+}
+
+// heifTypes are the content types whose size is read from the ispe property of the primary image
+var heifTypes = map[string]bool{
+	"image/avif": true,
+	"image/heic": true,
+}
+
+// decodeDimensions returns the size of the image file of the content type
+func decodeDimensions(path, contentType string) (int, int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	if heifTypes[contentType] {
+		return heifDimensions(f)
+	}
+	cfg, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return 0, 0, err
+	}
+	return cfg.Width, cfg.Height, nil
+}
+
+// imageDimensions returns the size of the image file, or zeros if its header can't be decoded
+func imageDimensions(path, contentType string) (int, int) {
+	width, height, err := decodeDimensions(path, contentType)
+	if err != nil {
+		return 0, 0
+	}
+	return width, height
+}
+
+// validateImage checks that a saved file is a usable image, rejecting empty files, HTML error pages, files of
+// an unknown type, images whose header does not decode, 1 pixel wide or high trackers and spacers, images smaller
+// than opts.MinWidth or opts.MinHeight or with fewer pixels than opts.MinPixels when those are set, images without
+// the aspect ratio opts.Aspect, and files whose detected type is not one of opts.Formats.
+// Known types whose size can't be read, such as SVG, ICO and video, are only checked for being non-empty and of an
+// allowed format; the skipped size check is logged when a size or aspect filter is set.
+func validateImage(img *savedImage, opts downloadOptions) error {
+	if img.Bytes == 0 {
+		return fmt.Errorf("empty file")
+	}
+	if img.ContentType == "text/html" {
+		return fmt.Errorf("HTML page instead of an image")
+	}
+	// Neither the bytes, the server nor the engine named one of the types in typeExtensions
+	if img.ContentType == "" {
+		return fmt.Errorf("unknown file type instead of an image")
+	}
+	if opts.Formats != nil && !opts.Formats[img.ContentType] {
+		return fmt.Errorf("%s is not one of the allowed formats", img.ContentType)
+	}
+	if !decodableTypes[img.ContentType] && !heifTypes[img.ContentType] {
+		if opts.MinWidth > 0 || opts.MinHeight > 0 || opts.MinPixels > 0 || opts.Aspect != "" {
+			slog.Info("Cannot check the size of image", "path", img.Path, "type", img.ContentType)
+		}
+		return nil
+	}
+
+	width, height, err := decodeDimensions(img.Path, img.ContentType)
+	if err != nil {
+		return fmt.Errorf("corrupt image: %v", err)
+	}
+	if width <= 1 || height <= 1 {
+		return fmt.Errorf("tracking pixel or spacer of %dx%d", width, height)
+	}
+	if width < opts.MinWidth || height < opts.MinHeight || width*height < opts.MinPixels {
+		return fmt.Errorf("%dx%d is below the minimum resolution", width, height)
+	}
+	if !searcher.MatchesAspect(opts.Aspect, width, height) {
+		return fmt.Errorf("%dx%d is not %s", width, height, opts.Aspect)
+	}
+	return nil
+}
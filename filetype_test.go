@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// box returns an ISO base media box of the kind holding the payload
+func box(kind string, payload ...[]byte) []byte {
+	data := bytes.Join(payload, nil)
+	header := binary.BigEndian.AppendUint32(nil, uint32(8+len(data)))
+	return append(append(header, kind...), data...)
+}
+
+// testAVIF returns the header of an AVIF file whose primary item 1 is width x height, rotated by rotation
+// quarter turns, after a thumbnail item 2 of 64x64
+func testAVIF(width, height uint32, rotation byte) []byte {
+	fullBox := []byte{0, 0, 0, 0}
+	ispe := func(w, h uint32) []byte {
+		return box("ispe", fullBox, binary.BigEndian.AppendUint32(binary.BigEndian.AppendUint32(nil, w), h))
+	}
+	ipco := box("ipco", ispe(64, 64), ispe(width, height), box("irot", []byte{rotation}))
+	// Item 2 has property 1, item 1 has properties 2 and 3, the first of them marked essential
+	ipma := box("ipma", fullBox, []byte{0, 0, 0, 2, 0, 2, 1, 1, 0, 1, 2, 0x82, 3})
+	meta := box("meta", fullBox, box("pitm", fullBox, []byte{0, 1}), box("iprp", ipco, ipma))
+	return append(append(box("ftyp", []byte("avif\x00\x00\x00\x00mif1")), meta...), box("mdat", []byte("pixels"))...)
+}
+
+// testWebP returns the header of a lossless WebP file of the size
+func testWebP(width, height uint32) []byte {
+	bits := (width - 1) | (height-1)<<14
+	vp8l := append([]byte{0x2f}, binary.LittleEndian.AppendUint32(nil, bits)...)
+	chunk := append(append([]byte("VP8L"), binary.LittleEndian.AppendUint32(nil, uint32(len(vp8l)))...), vp8l...)
+	return append(append([]byte("RIFF"), binary.LittleEndian.AppendUint32(nil, uint32(4+len(chunk)))...), append([]byte("WEBP"), chunk...)...)
+}
+
+func TestHEIFDimensions(t *testing.T) {
+	tests := []struct {
+		name          string
+		data          []byte
+		width, height int
+		err           string
+	}{
+		{"primary item", testAVIF(1920, 1080, 0), 1920, 1080, ""},
+		{"quarter turn", testAVIF(1920, 1080, 1), 1080, 1920, ""},
+		{"half turn", testAVIF(1920, 1080, 2), 1920, 1080, ""},
+		{"no meta box", box("ftyp", []byte("avif")), 0, 0, "no meta box"},
+		{"truncated", testAVIF(1920, 1080, 0)[:40], 0, 0, "truncated meta box"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			width, height, err := heifDimensions(bytes.NewReader(tt.data))
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("got error %v, want one containing %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil || width != tt.width || height != tt.height {
+				t.Errorf("got %dx%d and %v, want %dx%d", width, height, err, tt.width, tt.height)
+			}
+		})
+	}
+}
+
+func TestValidateImageSize(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        []byte
+		contentType string
+		opts        downloadOptions
+		err         string
+	}{
+		{"png", testPNG(t, color.White), "image/png", downloadOptions{MinWidth: 16}, ""},
+		{"small png", testPNG(t, color.White), "image/png", downloadOptions{MinWidth: 32}, "below the minimum"},
+		{"webp", testWebP(800, 600), "image/webp", downloadOptions{MinWidth: 800, MinHeight: 600}, ""},
+		{"small webp", testWebP(800, 600), "image/webp", downloadOptions{MinWidth: 1024}, "800x600 is below the minimum"},
+		{"webp of enough megapixels", testWebP(2000, 1000), "image/webp", downloadOptions{MinPixels: 2000000}, ""},
+		{"webp of too few megapixels", testWebP(1600, 1200), "image/webp", downloadOptions{MinPixels: 2000000}, "1600x1200 is below the minimum"},
+		{"webp of another aspect", testWebP(800, 600), "image/webp", downloadOptions{Aspect: "tall"}, "800x600 is not tall"},
+		{"avif", testAVIF(1920, 1080, 0), "image/avif", downloadOptions{MinWidth: 1920}, ""},
+		{"small avif", testAVIF(640, 480, 0), "image/avif", downloadOptions{MinHeight: 720}, "640x480 is below the minimum"},
+		{"rotated avif", testAVIF(1920, 1080, 1), "image/avif", downloadOptions{Aspect: "wide"}, "1080x1920 is not wide"},
+		{"avif without size", box("ftyp", []byte("avif")), "image/avif", downloadOptions{}, "corrupt image"},
+		{"svg", []byte("<svg/>"), "image/svg+xml", downloadOptions{MinWidth: 1024}, ""},
+		{"video", []byte("not sniffed"), "video/mp4", downloadOptions{}, ""},
+		{"unknown type", []byte("plain text"), "", downloadOptions{}, "unknown file type"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "image")
+			if err := os.WriteFile(path, tt.data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			img := &savedImage{Path: path, ContentType: tt.contentType, Bytes: int64(len(tt.data))}
+			err := validateImage(img, tt.opts)
+			if tt.err == "" && err != nil {
+				t.Errorf("got error %v, want the image kept", err)
+			}
+			if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Errorf("got error %v, want one containing %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestSniffContentType(t *testing.T) {
+	tests := []struct {
+		head []byte
+		want string
+	}{
+		{testAVIF(1, 1, 0), "image/avif"},
+		{box("ftyp", []byte("heic")), "image/heic"},
+		{testWebP(1, 1), "image/webp"},
+		{[]byte("II*\x00rest"), "image/tiff"},
+		{[]byte("  <?xml version=\"1.0\"?><svg/>"), "image/svg+xml"},
+		{[]byte("<!DOCTYPE html><html>"), "text/html"},
+		{[]byte("plain text"), ""},
+	}
+	for _, tt := range tests {
+		if got := sniffContentType(tt.head); got != tt.want {
+			t.Errorf("sniffContentType(%q) = %q, want %q", tt.head[:min(len(tt.head), 12)], got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/selman92/image-searcher/pkg/searcher"
+)
+
+// fileTarget is the engine name and folder given to the URLs of -from-file
+const fileTarget = "file"
+
+// loadURLList reads the image URLs of -from-file, one per line, ignoring blank lines and # comments.
+// A path of "-" reads standard input. Every URL becomes a result for query, or for the list's file name
+// if query is empty, so file names and sidecars look the same as for searched images.
+func loadURLList(path, query string) ([]searcher.Result, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read URL list: %v", err)
+		}
+		defer f.Close()
+		r = f
+	}
+	if query == "" {
+		query = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		if path == "-" {
+			query = "stdin"
+		}
+	}
+
+	var results []searcher.Result
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		imageURL := strings.TrimSpace(scanner.Text())
+		if imageURL == "" || strings.HasPrefix(imageURL, "#") {
+			continue
+		}
+		if u, err := url.Parse(imageURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid image URL on line %d of the URL list: %q", line, imageURL)
+		}
+		results = append(results, searcher.Result{URL: imageURL, Engine: fileTarget, Query: query})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read URL list: %v", err)
+	}
+	return results, nil
+}
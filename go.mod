@@ -1,10 +1,19 @@
-module image-searcher
+module github.com/selman92/image-searcher
 
 go 1.23.0
 
 require (
-	github.com/chromedp/cdproto v0.0.0-20240919203636-12af5e8a671f // indirect
-	github.com/chromedp/chromedp v0.10.0 // indirect
+	github.com/PuerkitoBio/goquery v1.10.3
+	github.com/chromedp/cdproto v0.0.0-20240919203636-12af5e8a671f
+	github.com/chromedp/chromedp v0.10.0
+	github.com/mattn/go-sqlite3 v1.14.24
+	github.com/schollz/progressbar/v3 v3.16.0
+	golang.org/x/image v0.25.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/andybalholm/cascadia v1.3.3 // indirect
 	github.com/chromedp/sysutil v1.0.0 // indirect
 	github.com/gobwas/httphead v0.1.0 // indirect
 	github.com/gobwas/pool v0.2.1 // indirect
@@ -13,7 +22,7 @@ require (
 	github.com/mailru/easyjson v0.7.7 // indirect
 	github.com/mitchellh/colorstring v0.0.0-20190213212951-d06e56a500db // indirect
 	github.com/rivo/uniseg v0.4.7 // indirect
-	github.com/schollz/progressbar/v3 v3.16.0 // indirect
-	golang.org/x/sys v0.25.0 // indirect
-	golang.org/x/term v0.24.0 // indirect
+	golang.org/x/net v0.39.0 // indirect
+	golang.org/x/sys v0.32.0 // indirect
+	golang.org/x/term v0.31.0 // indirect
 )
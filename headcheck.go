@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// headCheck asks the server about the image with a HEAD request and returns why it should not be
+// downloaded, or nil if it looks fine. Servers that refuse HEAD requests or leave out the headers
+// don't get the image skipped, the download validates it anyway.
+func headCheck(ctx context.Context, client *http.Client, job downloadJob, opts downloadOptions) error {
+	resp, err := headRequest(ctx, client, job, opts)
+	if err != nil {
+		return fmt.Errorf("unreachable: %v", err)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return fmt.Errorf("dead link: %s", resp.Status)
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return nil
+	}
+
+	if contentType := resp.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err == nil && !downloadableType(mediaType) {
+			return fmt.Errorf("not an image: %s", mediaType)
+		}
+	}
+	if opts.MaxFileSize > 0 && resp.ContentLength > opts.MaxFileSize {
+		return fmt.Errorf("%d bytes is over the size limit", resp.ContentLength)
+	}
+	return nil
+}
+
+// headRequest sends a HEAD request for the job's image with the headers of its download and returns the
+// response, whose body is already closed
+func headRequest(ctx context.Context, client *http.Client, job downloadJob, opts downloadOptions) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, job.Result.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = imageHeader(job.Result, opts.Headers)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return resp, nil
+}
+
+// downloadableType reports whether a Content-Type may be an image worth downloading.
+// Generic binary types are allowed since many hosts serve images with them, and video
+// for the MP4 renditions of animated GIF engines.
+func downloadableType(mediaType string) bool {
+	return strings.HasPrefix(mediaType, "image/") || strings.HasPrefix(mediaType, "video/") ||
+		mediaType == "application/octet-stream" || mediaType == "binary/octet-stream"
+}
+
+// headFilter checks every job with a HEAD request before any image is downloaded and returns the jobs
+// that passed, in their original order. At most opts.HeadConcurrency requests are in flight at once and
+// every request waits for the limiter of its host.
+func headFilter(ctx context.Context, client *http.Client, limiter *hostLimiter, jobs []downloadJob, opts downloadOptions) []downloadJob {
+	bar := opts.Progress.stage("Checking images", "skipped", len(jobs))
+	keep := make([]bool, len(jobs))
+	forEachJob(ctx, len(jobs), opts.HeadConcurrency, func(i int) {
+		keep[i] = headCheckJob(ctx, client, limiter, jobs[i], opts)
+		bar.add(!keep[i], 0)
+	})
+	bar.finish()
+
+	var passed []downloadJob
+	for i, job := range jobs {
+		if keep[i] {
+			passed = append(passed, job)
+		}
+	}
+	return passed
+}
+
+// forEachJob calls fn with the index of every job from a pool of workers and waits for them to return.
+// Once ctx is cancelled no new jobs are started.
+func forEachJob(ctx context.Context, jobs, workers int, fn func(i int)) {
+	queue := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(max(workers, 1), jobs); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				fn(i)
+			}
+		}()
+	}
+
+feed:
+	for i := 0; i < jobs; i++ {
+		select {
+		case queue <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(queue)
+	wg.Wait()
+}
+
+// headCheckJob runs the HEAD check of a single job through its host limiter and proxy, logging why it is skipped
+func headCheckJob(ctx context.Context, client *http.Client, limiter *hostLimiter, job downloadJob, opts downloadOptions) bool {
+	if job.Result.Data != nil {
+		// Images captured from the browser are saved without another request
+		return true
+	}
+	release, err := limiter.wait(ctx, job.Result.URL)
+	if err != nil {
+		return false
+	}
+	defer release()
+
+	if opts.Proxies != nil {
+		ctx = withProxy(ctx, opts.Proxies.pick(job.Result.Engine))
+	}
+	if opts.Robots != nil && !opts.Robots.allowed(ctx, job.Result.URL) {
+		if ctx.Err() == nil {
+			slog.Debug("Skipping image disallowed by robots.txt", "engine", job.Result.Engine, "index", job.Index, "url", job.Result.URL)
+			if opts.Report != nil {
+				opts.Report(jobOutcome(job, statusSkipped, "disallowed by robots.txt"))
+			}
+		}
+		return false
+	}
+	if err := headCheck(ctx, client, job, opts); err != nil {
+		if ctx.Err() == nil {
+			slog.Debug("Skipping image after HEAD check", "engine", job.Result.Engine, "index", job.Index, "url", job.Result.URL, "reason", err)
+			if opts.Report != nil {
+				opts.Report(jobOutcome(job, statusSkipped, err.Error()))
+			}
+		}
+		return false
+	}
+	return true
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// maxMetaBox caps how much of an AVIF or HEIC file's meta box is read to find its dimensions
+const maxMetaBox = 1 << 20
+
+// heifBox is an ISO base media box: its four-character type and its payload
+type heifBox struct {
+	kind    string
+	payload []byte
+}
+
+// readHEIFBoxes splits data into the boxes it contains
+func readHEIFBoxes(data []byte) ([]heifBox, error) {
+	var boxes []heifBox
+	for len(data) > 0 {
+		if len(data) < 8 {
+			return nil, fmt.Errorf("truncated box header")
+		}
+		size, header := uint64(binary.BigEndian.Uint32(data)), uint64(8)
+		kind := string(data[4:8])
+		switch size {
+		case 0:
+			size = uint64(len(data))
+		case 1:
+			if len(data) < 16 {
+				return nil, fmt.Errorf("truncated %s box header", kind)
+			}
+			size, header = binary.BigEndian.Uint64(data[8:]), 16
+		}
+		if size < header || size > uint64(len(data)) {
+			return nil, fmt.Errorf("invalid size of %s box", kind)
+		}
+		boxes = append(boxes, heifBox{kind: kind, payload: data[header:size]})
+		data = data[size:]
+	}
+	return boxes, nil
+}
+
+// findHEIFBox returns the payload of the first box of the kind, or nil
+func findHEIFBox(boxes []heifBox, kind string) []byte {
+	for _, box := range boxes {
+		if box.kind == kind {
+			return box.payload
+		}
+	}
+	return nil
+}
+
+// heifMetaBox reads the top-level meta box of an AVIF or HEIC file, which comes before the image data
+func heifMetaBox(r io.ReaderAt) ([]byte, error) {
+	var offset int64
+	header := make([]byte, 16)
+	for {
+		if _, err := r.ReadAt(header[:8], offset); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("no meta box")
+			}
+			return nil, err
+		}
+		size, headerLen := int64(binary.BigEndian.Uint32(header)), int64(8)
+		kind := string(header[4:8])
+		if size == 1 {
+			if _, err := r.ReadAt(header[8:16], offset+8); err != nil {
+				return nil, fmt.Errorf("truncated %s box header", kind)
+			}
+			size, headerLen = int64(binary.BigEndian.Uint64(header[8:])), 16
+		}
+		if kind == "meta" {
+			if size == 0 || size-headerLen > maxMetaBox {
+				return nil, fmt.Errorf("meta box too large")
+			}
+			payload := make([]byte, size-headerLen)
+			if _, err := r.ReadAt(payload, offset+headerLen); err != nil {
+				return nil, fmt.Errorf("truncated meta box")
+			}
+			return payload, nil
+		}
+		if size == 0 {
+			return nil, fmt.Errorf("no meta box")
+		}
+		if size < headerLen {
+			return nil, fmt.Errorf("invalid size of %s box", kind)
+		}
+		offset += size
+	}
+}
+
+// heifDimensions returns the size of the primary image of an AVIF or HEIC file from its ispe property,
+// swapped when an irot property rotates the image by a quarter turn
+func heifDimensions(r io.ReaderAt) (int, int, error) {
+	meta, err := heifMetaBox(r)
+	if err != nil {
+		return 0, 0, err
+	}
+	// meta is a full box: its children follow a version and flags
+	if len(meta) < 4 {
+		return 0, 0, fmt.Errorf("truncated meta box")
+	}
+	boxes, err := readHEIFBoxes(meta[4:])
+	if err != nil {
+		return 0, 0, err
+	}
+
+	pitm := findHEIFBox(boxes, "pitm")
+	if len(pitm) < 6 {
+		return 0, 0, fmt.Errorf("no primary item")
+	}
+	primary := uint32(binary.BigEndian.Uint16(pitm[4:]))
+	if pitm[0] > 0 {
+		if len(pitm) < 8 {
+			return 0, 0, fmt.Errorf("truncated pitm box")
+		}
+		primary = binary.BigEndian.Uint32(pitm[4:])
+	}
+
+	iprp, err := readHEIFBoxes(findHEIFBox(boxes, "iprp"))
+	if err != nil {
+		return 0, 0, err
+	}
+	properties, err := readHEIFBoxes(findHEIFBox(iprp, "ipco"))
+	if err != nil {
+		return 0, 0, err
+	}
+	indexes, err := heifItemProperties(findHEIFBox(iprp, "ipma"), primary)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var width, height uint32
+	var rotated bool
+	for _, index := range indexes {
+		// Property indexes are 1-based, 0 means no property
+		if index == 0 || index > len(properties) {
+			continue
+		}
+		property := properties[index-1]
+		switch property.kind {
+		case "ispe":
+			if len(property.payload) >= 12 {
+				width = binary.BigEndian.Uint32(property.payload[4:])
+				height = binary.BigEndian.Uint32(property.payload[8:])
+			}
+		case "irot":
+			if len(property.payload) >= 1 {
+				rotated = property.payload[0]&1 == 1
+			}
+		}
+	}
+	if width == 0 || height == 0 {
+		return 0, 0, fmt.Errorf("no image size")
+	}
+	if rotated {
+		width, height = height, width
+	}
+	return int(width), int(height), nil
+}
+
+// heifItemProperties returns the indexes of the properties the ipma box associates with the item
+func heifItemProperties(ipma []byte, item uint32) ([]int, error) {
+	if len(ipma) < 8 {
+		return nil, fmt.Errorf("no item properties")
+	}
+	version, flags := ipma[0], ipma[3]
+	count := binary.BigEndian.Uint32(ipma[4:])
+	data := ipma[8:]
+	for ; count > 0; count-- {
+		var id uint32
+		if version < 1 {
+			if len(data) < 2 {
+				break
+			}
+			id, data = uint32(binary.BigEndian.Uint16(data)), data[2:]
+		} else {
+			if len(data) < 4 {
+				break
+			}
+			id, data = binary.BigEndian.Uint32(data), data[4:]
+		}
+		if len(data) < 1 {
+			break
+		}
+		associations := int(data[0])
+		data = data[1:]
+
+		var indexes []int
+		for ; associations > 0; associations-- {
+			// The top bit marks essential properties, the rest is the index
+			if flags&1 == 1 {
+				if len(data) < 2 {
+					return nil, fmt.Errorf("truncated ipma box")
+				}
+				indexes, data = append(indexes, int(binary.BigEndian.Uint16(data)&0x7fff)), data[2:]
+			} else {
+				if len(data) < 1 {
+					return nil, fmt.Errorf("truncated ipma box")
+				}
+				indexes, data = append(indexes, int(data[0]&0x7f)), data[1:]
+			}
+		}
+		if id == item {
+			return indexes, nil
+		}
+	}
+	return nil, fmt.Errorf("no properties of the primary item")
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+)
+
+// newLogHandler returns the handler writing the run's log records to w as "text" or "json", at level and above
+func newLogHandler(w io.Writer, format string, level slog.Level) (slog.Handler, error) {
+	opts := &slog.HandlerOptions{Level: level}
+	switch format {
+	case "text":
+		return slog.NewTextHandler(w, opts), nil
+	case "json":
+		return slog.NewJSONHandler(w, opts), nil
+	default:
+		return nil, fmt.Errorf("invalid -log-format %q, expected text or json", format)
+	}
+}
+
+// logLevel returns the level of -verbose and -quiet
+func logLevel(verbose, quiet bool) slog.Level {
+	switch {
+	case verbose:
+		return slog.LevelDebug
+	case quiet:
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// fatalf logs a setup error, prints it to standard error since the log file may not be looked at, and exits with 1
+func fatalf(format string, args ...any) {
+	message := fmt.Sprintf(format, args...)
+	slog.Error(message)
+	fmt.Fprintln(os.Stderr, "Error: "+message)
+	os.Exit(1)
+}
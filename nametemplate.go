@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultNameTemplate names files like the tool always has, e.g. cats12.jpg
+const defaultNameTemplate = "{query}{index}{ext}"
+
+// namePlaceholderPattern matches a {placeholder} in a name template
+var namePlaceholderPattern = regexp.MustCompile(`\{([a-z0-9]+)\}`)
+
+// namePlaceholders are the placeholders a name template may use, and whether they need the downloaded contents
+var namePlaceholders = map[string]bool{
+	"query":  false,
+	"engine": false,
+	"index":  false,
+	"date":   false,
+	"domain": false,
+	"hash":   true,
+	"hash8":  true,
+	"width":  true,
+	"height": true,
+}
+
+// unsafeNameChars are replaced in placeholder values so they can't escape the folder or break file names
+var unsafeNameChars = regexp.MustCompile(`[/\\:*?"<>|\x00-\x1f]`)
+
+// nameTemplate builds file names from -name-template. The extension is always added at the end,
+// so {ext} may only appear as the last placeholder.
+type nameTemplate struct {
+	base         string // Template without the trailing {ext}
+	needsContent bool   // Whether the name can only be known once the image is downloaded
+}
+
+// nameFields are the values available to a name template
+type nameFields struct {
+	Query  string
+	Engine string
+	Index  int
+	URL    string
+	SHA256 string
+	Width  int
+	Height int
+}
+
+// parseNameTemplate validates a name template like "{query}_{engine}_{index}_{hash8}{ext}"
+func parseNameTemplate(template string) (nameTemplate, error) {
+	base := strings.TrimSuffix(template, "{ext}")
+	if strings.Contains(base, "{ext}") {
+		return nameTemplate{}, fmt.Errorf("invalid name template %q: {ext} must come last", template)
+	}
+
+	t := nameTemplate{base: base}
+	for _, match := range namePlaceholderPattern.FindAllStringSubmatch(base, -1) {
+		needsContent, ok := namePlaceholders[match[1]]
+		if !ok {
+			return nameTemplate{}, fmt.Errorf("invalid name template %q: unknown placeholder {%s}", template, match[1])
+		}
+		t.needsContent = t.needsContent || needsContent
+	}
+	if base == "" {
+		return nameTemplate{}, fmt.Errorf("invalid name template %q: the name is empty", template)
+	}
+	return t, nil
+}
+
+// render returns the file name for the fields, without the extension
+func (t nameTemplate) render(f nameFields) string {
+	return namePlaceholderPattern.ReplaceAllStringFunc(t.base, func(placeholder string) string {
+		var value string
+		switch strings.Trim(placeholder, "{}") {
+		case "query":
+			value = f.Query
+		case "engine":
+			value = f.Engine
+		case "index":
+			value = strconv.Itoa(f.Index)
+		case "date":
+			value = time.Now().Format("2006-01-02")
+		case "domain":
+			if u, err := url.Parse(f.URL); err == nil {
+				value = u.Hostname()
+			}
+		case "hash":
+			value = f.SHA256
+		case "hash8":
+			value = f.SHA256[:min(8, len(f.SHA256))]
+		case "width":
+			value = strconv.Itoa(f.Width)
+		case "height":
+			value = strconv.Itoa(f.Height)
+		}
+		return unsafeNameChars.ReplaceAllString(value, "_")
+	})
+}
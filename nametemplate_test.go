@@ -0,0 +1,73 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseNameTemplate(t *testing.T) {
+	tests := []struct {
+		template     string
+		needsContent bool
+		err          string
+	}{
+		{defaultNameTemplate, false, ""},
+		{"{query}_{engine}_{index}_{hash8}{ext}", true, ""},
+		{"{domain}-{width}x{height}", true, ""},
+		{"{date}/{query}", false, ""},
+		{"{query}{ext}.bak", false, "{ext} must come last"},
+		{"{query}_{size}{ext}", false, "unknown placeholder {size}"},
+		{"{ext}", false, "the name is empty"},
+	}
+	for _, tt := range tests {
+		template, err := parseNameTemplate(tt.template)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("parseNameTemplate(%q): got error %v, want one containing %q", tt.template, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || template.needsContent != tt.needsContent {
+			t.Errorf("parseNameTemplate(%q) = %+v, %v, want needsContent %v", tt.template, template, err, tt.needsContent)
+		}
+	}
+}
+
+func TestNameTemplateRender(t *testing.T) {
+	fields := nameFields{
+		Query:  `cats/dogs: "cute"`,
+		Engine: "bing",
+		Index:  12,
+		URL:    "https://images.example.com:8443/a/b.jpg?x=1",
+		SHA256: "0123456789abcdef",
+		Width:  640,
+		Height: 480,
+	}
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{defaultNameTemplate, "cats_dogs_ _cute_12"},
+		{"{engine}_{index}_{hash8}{ext}", "bing_12_01234567"},
+		{"{domain}-{width}x{height}", "images.example.com-640x480"},
+		{"{hash}", "0123456789abcdef"},
+		{"{date}", time.Now().Format("2006-01-02")},
+		{"img-{index}", "img-12"},
+	}
+	for _, tt := range tests {
+		template, err := parseNameTemplate(tt.template)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := template.render(fields); got != tt.want {
+			t.Errorf("%q rendered %q, want %q", tt.template, got, tt.want)
+		}
+	}
+
+	// A short or missing hash must not panic
+	template, _ := parseNameTemplate("{hash8}")
+	if got := template.render(nameFields{SHA256: "abc"}); got != "abc" {
+		t.Errorf("got %q for a short hash", got)
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/selman92/image-searcher/pkg/searcher"
+)
+
+// resultRecord is a search result as printed by -urls-only -urls-format json, and with the outcome
+// of its download as streamed by -output-format jsonl
+type resultRecord struct {
+	URL         string `json:"url"`
+	PageURL     string `json:"page_url,omitempty"`
+	Engine      string `json:"engine"`
+	Query       string `json:"query"`
+	Title       string `json:"title,omitempty"`
+	Width       int    `json:"width,omitempty"`
+	Height      int    `json:"height,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	License     string `json:"license,omitempty"`
+	Author      string `json:"author,omitempty"`
+	Path        string `json:"path,omitempty"`
+	Status      string `json:"status,omitempty"`
+	Reason      string `json:"reason,omitempty"`
+}
+
+// newResultRecord returns the record of a search result
+func newResultRecord(result searcher.Result) resultRecord {
+	return resultRecord{
+		URL:         result.URL,
+		PageURL:     result.PageURL,
+		Engine:      result.Engine,
+		Query:       result.Query,
+		Title:       result.Title,
+		Width:       result.Width,
+		Height:      result.Height,
+		ContentType: result.ContentType,
+		License:     result.License,
+		Author:      result.Author,
+	}
+}
+
+// outcomeRecord returns the record of a finished download job. The dimensions are those of the saved
+// image when they could be read, otherwise those the engine reported.
+func outcomeRecord(outcome downloadOutcome) resultRecord {
+	record := newResultRecord(outcome.Job.Result)
+	record.Path = outcome.Path
+	record.Status = string(outcome.Status)
+	record.Reason = outcome.Reason
+	if outcome.Width > 0 && outcome.Height > 0 {
+		record.Width, record.Height = outcome.Width, outcome.Height
+	}
+	return record
+}
+
+// jsonlWriter streams records as JSON Lines, one object per line. It is safe for concurrent use.
+type jsonlWriter struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+}
+
+func newJSONLWriter(w io.Writer) *jsonlWriter {
+	return &jsonlWriter{encoder: json.NewEncoder(w)}
+}
+
+// write writes the record as a single line
+func (j *jsonlWriter) write(record resultRecord) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.encoder.Encode(record)
+}
+
+// writeURLs prints the results of every target in order instead of downloading them, either one URL
+// per line ("text") or as a JSON array of result records ("json")
+func writeURLs(w io.Writer, targets []string, searches []querySearch, format string) error {
+	records := []resultRecord{}
+	for _, s := range searches {
+		for _, target := range targets {
+			for _, result := range s.Found[target] {
+				if format == "text" {
+					if _, err := fmt.Fprintln(w, result.URL); err != nil {
+						return err
+					}
+					continue
+				}
+				records = append(records, newResultRecord(result))
+			}
+		}
+	}
+	if format == "text" {
+		return nil
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(records)
+}
+
+// manifestHeader is the first row of manifest.csv
+var manifestHeader = []string{"filename", "source_url", "engine", "query", "status", "http_status", "bytes", "sha256", "time", "reason"}
+
+// manifestWriter appends a row for every attempted download to manifest.csv. It is safe for concurrent use.
+type manifestWriter struct {
+	dir string // Output directory file names are made relative to
+
+	mu   sync.Mutex
+	file *os.File
+	csv  *csv.Writer
+}
+
+// openManifest opens the CSV manifest at path for appending, writing the header if the file is new.
+// Reruns into the same output directory add to the manifest of earlier runs.
+func openManifest(path, dir string) (*manifestWriter, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open manifest: %v", err)
+	}
+	m := &manifestWriter{dir: dir, file: file, csv: csv.NewWriter(file)}
+	if info, err := file.Stat(); err == nil && info.Size() == 0 {
+		m.csv.Write(manifestHeader)
+	}
+	return m, nil
+}
+
+// write appends the row of a finished job, flushing it right away so the manifest survives a crash
+func (m *manifestWriter) write(outcome downloadOutcome) error {
+	filename := outcome.Path
+	if rel, err := filepath.Rel(m.dir, outcome.Path); err == nil && outcome.Path != "" {
+		filename = rel
+	}
+	statusCode := ""
+	if outcome.StatusCode != 0 {
+		statusCode = strconv.Itoa(outcome.StatusCode)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.csv.Write([]string{
+		filename,
+		outcome.Job.Result.URL,
+		outcome.Job.Result.Engine,
+		outcome.Job.Result.Query,
+		string(outcome.Status),
+		statusCode,
+		strconv.FormatInt(outcome.Bytes, 10),
+		outcome.SHA256,
+		outcome.Time.Format(time.RFC3339),
+		outcome.Reason,
+	})
+	m.csv.Flush()
+	return m.csv.Error()
+}
+
+func (m *manifestWriter) Close() error {
+	return m.file.Close()
+}
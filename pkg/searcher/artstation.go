@@ -0,0 +1,110 @@
+package searcher
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+func init() {
+	Register(ArtStation{})
+}
+
+// artStationPageSize is how many projects are requested per page
+const artStationPageSize = 50
+
+// ArtStation searches ArtStation projects through the JSON endpoints its web app uses, without a browser.
+// With Options.FullRes every image asset of each project is returned, otherwise only the project covers.
+type ArtStation struct{}
+
+// Name returns the engine name
+func (ArtStation) Name() string {
+	return "artstation"
+}
+
+// SearchURL returns the project search URL for the query and 1-based page
+func (ArtStation) SearchURL(query string, page int) string {
+	return "https://www.artstation.com/api/v2/search/projects.json?" + url.Values{
+		"query":    {query},
+		"page":     {strconv.Itoa(page)},
+		"per_page": {strconv.Itoa(artStationPageSize)},
+		"sorting":  {"relevance"},
+	}.Encode()
+}
+
+// artStationSearchResponse is the response of the project search endpoint
+type artStationSearchResponse struct {
+	TotalCount int `json:"total_count"`
+	Data       []struct {
+		HashID string `json:"hash_id"`
+		Title  string `json:"title"`
+		URL    string `json:"url"`
+		Cover  struct {
+			SmallerSquareCoverURL string `json:"smaller_square_cover_url"`
+		} `json:"cover"`
+		SmallerSquareCoverURL string `json:"smaller_square_cover_url"`
+		User                  struct {
+			FullName string `json:"full_name"`
+		} `json:"user"`
+	} `json:"data"`
+}
+
+// artStationProject is the response of the project details endpoint
+type artStationProject struct {
+	Assets []struct {
+		AssetType string `json:"asset_type"`
+		ImageURL  string `json:"image_url"`
+		Width     int    `json:"width"`
+		Height    int    `json:"height"`
+	} `json:"assets"`
+}
+
+// Search pages through the project search results
+func (a ArtStation) Search(ctx context.Context, query string, opts Options) ([]Result, error) {
+	var results []Result
+	for page := 1; page <= maxPages(opts); page++ {
+		if opts.Limit > 0 && len(results) >= opts.Limit {
+			break
+		}
+
+		var resp artStationSearchResponse
+		if err := fetchJSON(ctx, opts, a.SearchURL(query, page), nil, &resp); err != nil {
+			return nil, fmt.Errorf("failed to fetch ArtStation projects: %v", err)
+		}
+
+		for _, project := range resp.Data {
+			result := Result{PageURL: project.URL, Engine: a.Name(), Query: query, Title: project.Title, Author: project.User.FullName}
+
+			if !opts.FullRes {
+				result.URL = project.SmallerSquareCoverURL
+				if result.URL == "" {
+					result.URL = project.Cover.SmallerSquareCoverURL
+				}
+				if result.URL != "" {
+					results = append(results, result)
+				}
+				continue
+			}
+
+			var details artStationProject
+			detailsURL := fmt.Sprintf("https://www.artstation.com/projects/%s.json", url.PathEscape(project.HashID))
+			if err := fetchJSON(ctx, opts, detailsURL, nil, &details); err != nil {
+				continue
+			}
+			for _, asset := range details.Assets {
+				if asset.AssetType != "image" || asset.ImageURL == "" {
+					continue
+				}
+				result.URL, result.Width, result.Height = asset.ImageURL, asset.Width, asset.Height
+				results = append(results, result)
+			}
+		}
+
+		if page*artStationPageSize >= resp.TotalCount {
+			break
+		}
+	}
+
+	return LimitResults(results, opts.Limit), nil
+}
@@ -0,0 +1,190 @@
+package searcher
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+// DefaultBackend is the name of the browser backend used when Options.Browser.Backend is empty
+const DefaultBackend = "chromedp"
+
+// BrowserBackend is a browser automation library driving the tabs of browser-based engines. chromedp is built in;
+// others, e.g. on go-rod or playwright-go, are plugged in with RegisterBackend and picked with Options.Browser.Backend.
+type BrowserBackend interface {
+	// Name returns the unique name used to select the backend, e.g. "chromedp"
+	Name() string
+	// NewBrowser starts a browser to share between searches. Tabs opened with the returned context, or one derived
+	// from it, are opened in it, and the returned cancel function shuts it down.
+	NewBrowser(ctx context.Context, opts Options) (context.Context, context.CancelFunc, error)
+	// OpenTab opens a tab for a search, set up with the browser settings of opts like the proxy, cookies, language
+	// and emulation, in the shared browser of ctx if it carries one
+	OpenTab(ctx context.Context, opts Options) (BrowserTab, error)
+}
+
+// BrowserTab is a tab opened by a BrowserBackend. It is bound to the context it was opened with, and its methods
+// are called one at a time.
+type BrowserTab interface {
+	// Navigate loads the URL in the tab and waits for the page to load
+	Navigate(url string) error
+	// Evaluate runs the JavaScript expression in the page and decodes its JSON value into result unless it is nil
+	Evaluate(expression string, result any) error
+	// HTML returns the source of the page as currently rendered
+	HTML() (string, error)
+	// Location returns the URL of the page
+	Location() (string, error)
+	// Scroll scrolls to the bottom of the page
+	Scroll() error
+	// Close closes the tab, or shuts the browser down if it was started for the tab alone
+	Close()
+}
+
+var (
+	backendsMu   sync.RWMutex
+	backends     = make(map[string]BrowserBackend)
+	backendNames []string
+)
+
+func init() {
+	RegisterBackend(chromedpBackend{})
+}
+
+// RegisterBackend makes a browser backend available by its name. It panics if a backend with the same name is
+// already registered.
+func RegisterBackend(backend BrowserBackend) {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+
+	name := backend.Name()
+	if _, exists := backends[name]; exists {
+		panic(fmt.Sprintf("searcher: browser backend %q registered twice", name))
+	}
+	backends[name] = backend
+	backendNames = append(backendNames, name)
+}
+
+// LookupBackend returns the browser backend registered with the given name
+func LookupBackend(name string) (BrowserBackend, bool) {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+
+	backend, ok := backends[name]
+	return backend, ok
+}
+
+// BackendNames returns the names of all registered browser backends in registration order
+func BackendNames() []string {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+
+	return append([]string(nil), backendNames...)
+}
+
+// backend returns the browser backend of opts.Browser.Backend, or DefaultBackend if it is empty
+func backend(opts Options) (BrowserBackend, error) {
+	name := opts.Browser.Backend
+	if name == "" {
+		name = DefaultBackend
+	}
+	b, ok := LookupBackend(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown browser backend: %s", name)
+	}
+	return b, nil
+}
+
+// openTab opens a tab for a browser-based search with the backend of opts.Browser.Backend
+func openTab(ctx context.Context, opts Options) (BrowserTab, error) {
+	if opts.NoBrowser {
+		return nil, ErrNoBrowser
+	}
+	b, err := backend(opts)
+	if err != nil {
+		return nil, err
+	}
+	return b.OpenTab(ctx, opts)
+}
+
+// sleep waits for the duration, returning early with the error of ctx if it is done first
+func sleep(ctx context.Context, d time.Duration) error {
+	select {
+	case <-time.After(d):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// chromedpBackend drives Chrome through the DevTools protocol with chromedp
+type chromedpBackend struct{}
+
+// Name returns the backend name
+func (chromedpBackend) Name() string {
+	return "chromedp"
+}
+
+// NewBrowser starts a browser with newChromedpBrowser
+func (chromedpBackend) NewBrowser(ctx context.Context, opts Options) (context.Context, context.CancelFunc, error) {
+	return newChromedpBrowser(ctx, opts)
+}
+
+// OpenTab opens a tab with NewBrowserContext
+func (chromedpBackend) OpenTab(ctx context.Context, opts Options) (BrowserTab, error) {
+	taskCtx, cancel, err := NewBrowserContext(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &chromedpTab{ctx: taskCtx, cancel: cancel}, nil
+}
+
+// chromedpTab is a tab opened by chromedpBackend
+type chromedpTab struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// Navigate loads the URL and waits for the load event
+func (t *chromedpTab) Navigate(url string) error {
+	return chromedp.Run(t.ctx, chromedp.Navigate(url))
+}
+
+// Evaluate runs the expression with chromedp.Evaluate
+func (t *chromedpTab) Evaluate(expression string, result any) error {
+	return chromedp.Run(t.ctx, chromedp.Evaluate(expression, result))
+}
+
+// HTML returns the outer HTML of the document element
+func (t *chromedpTab) HTML() (string, error) {
+	var html string
+	err := chromedp.Run(t.ctx, chromedp.OuterHTML("html", &html, chromedp.ByQuery))
+	return html, err
+}
+
+// Location returns the URL of the page
+func (t *chromedpTab) Location() (string, error) {
+	var location string
+	err := chromedp.Run(t.ctx, chromedp.Location(&location))
+	return location, err
+}
+
+// Scroll scrolls down by the height of the page
+func (t *chromedpTab) Scroll() error {
+	return t.Evaluate(`window.scrollBy(0, document.body.scrollHeight);`, nil)
+}
+
+// Close closes the tab or shuts its browser down
+func (t *chromedpTab) Close() {
+	t.cancel()
+}
+
+// capturedImages returns the images captured with Options.Browser.CaptureImages, ending the capture
+func (t *chromedpTab) capturedImages() ([]capturedImage, bool) {
+	capture, ok := t.ctx.Value(imageCaptureKey{}).(*imageCapture)
+	if !ok {
+		return nil, false
+	}
+	return capture.stop(), true
+}
@@ -0,0 +1,56 @@
+package searcher
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/chromedp/chromedp"
+)
+
+// fakeTab is a BrowserTab whose expressions evaluate to canned JSON values, decoded like chromedp decodes them
+type fakeTab struct {
+	values    map[string]string // JSON value of every expression, null for those missing
+	location  string
+	evaluated []string
+	scrolls   int
+}
+
+func (t *fakeTab) Navigate(url string) error {
+	t.location = url
+	return nil
+}
+
+func (t *fakeTab) Evaluate(expression string, result any) error {
+	t.evaluated = append(t.evaluated, expression)
+	value, ok := t.values[expression]
+	if !ok {
+		value = "null"
+	}
+	if result == nil {
+		return nil
+	}
+	// chromedp fails null values that the result can't hold
+	if value == "null" {
+		switch reflect.ValueOf(result).Elem().Kind() {
+		case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Chan, reflect.Func, reflect.Interface:
+		default:
+			return chromedp.ErrJSNull
+		}
+	}
+	return json.Unmarshal([]byte(value), result)
+}
+
+func (t *fakeTab) HTML() (string, error) {
+	return "<html></html>", nil
+}
+
+func (t *fakeTab) Location() (string, error) {
+	return t.location, nil
+}
+
+func (t *fakeTab) Scroll() error {
+	t.scrolls++
+	return nil
+}
+
+func (t *fakeTab) Close() {}
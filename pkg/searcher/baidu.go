@@ -0,0 +1,109 @@
+package searcher
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+func init() {
+	Register(Baidu{})
+}
+
+// baiduScroll counts the result items on the page; Baidu loads more results on scroll without a button
+var baiduScroll = scrollStrategy{
+	countJS: `document.querySelectorAll('li.imgitem, .imgitem, [data-objurl]').length`,
+}
+
+var (
+	// baiduObjURLPattern matches original image URLs in the embedded JSON and in item attributes
+	baiduObjURLPattern = regexp.MustCompile(`(?:"objURL":"|data-objurl=")([^"]+)"`)
+	// baiduThumbURLPattern matches thumbnail URLs in the embedded JSON and in item attributes
+	baiduThumbURLPattern = regexp.MustCompile(`(?:"thumbURL":"|data-thumburl=")([^"]+)"`)
+)
+
+// Baidu searches Baidu Images with a headless browser
+type Baidu struct{}
+
+// Name returns the engine name
+func (Baidu) Name() string {
+	return "baidu"
+}
+
+// UsesBrowser reports that the engine drives a browser
+func (Baidu) UsesBrowser() bool {
+	return true
+}
+
+// SearchURL returns the Baidu image search page URL for the query
+func (Baidu) SearchURL(query string) string {
+	return "https://image.baidu.com/search/index?" + url.Values{"tn": {"baiduimage"}, "word": {query}}.Encode()
+}
+
+// Search searches for images on Baidu using chromedp and returns the objURL values, or the thumbURL values
+// when opts.FullRes is off
+func (b Baidu) Search(ctx context.Context, query string, opts Options) ([]Result, error) {
+	var html string
+	searchURL := b.SearchURL(query)
+
+	tab, err := openTab(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer tab.Close()
+
+	// Load the Baidu image search page and scroll down to trigger lazy loading (simulate user interaction)
+	err = loadResultsPage(ctx, tab, b.Name(), searchURL, opts)
+	if err == nil {
+		err = scrollPage(ctx, tab, baiduScroll, 5, opts)
+	}
+	if err == nil {
+		// Keep the page source, which embeds the result list as JSON and as item attributes
+		html, err = tab.HTML()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch Baidu images: %w", err)
+	}
+
+	if captured := capturedResults(tab, b.Name(), query, searchURL); captured != nil {
+		return LimitResults(captured, opts.Limit), nil
+	}
+
+	pattern := baiduThumbURLPattern
+	if opts.FullRes {
+		pattern = baiduObjURLPattern
+	}
+	imageURLs := parseEmbeddedURLs(html, pattern, decodeBaiduURL)
+
+	return LimitResults(newResults(imageURLs, b.Name(), query, searchURL), opts.Limit), nil
+}
+
+var (
+	// baiduTokenReplacer restores the multi-character tokens of an obfuscated objURL
+	baiduTokenReplacer = strings.NewReplacer("_z2C$q", ":", "_z&e3B", ".", "AzdH3F", "/")
+	// baiduCharMap maps the substituted characters of an obfuscated objURL back to the original ones
+	baiduCharMap = map[rune]rune{
+		'w': 'a', 'k': 'b', 'v': 'c', '1': 'd', 'j': 'e', 'u': 'f', '2': 'g', 'i': 'h', 't': 'i', '3': 'j',
+		'h': 'k', 's': 'l', '4': 'm', 'g': 'n', '5': 'o', 'r': 'p', 'q': 'q', '6': 'r', 'f': 's', 'p': 't',
+		'7': 'u', 'e': 'v', 'o': 'w', '8': '1', 'd': '2', 'n': '3', '9': '4', 'c': '5', 'm': '6', '0': '7',
+		'b': '8', 'l': '9', 'a': '0',
+	}
+)
+
+// DecodeBaiduURL decodes the obfuscated objURL format used by some Baidu result lists (e.g. "ippr_z2C$qAzdH3F...").
+// URLs that are not obfuscated are returned unchanged.
+func decodeBaiduURL(objURL string) string {
+	if !strings.HasPrefix(objURL, "ippr") {
+		return objURL
+	}
+
+	decoded := baiduTokenReplacer.Replace(objURL)
+	return strings.Map(func(r rune) rune {
+		if mapped, ok := baiduCharMap[r]; ok {
+			return mapped
+		}
+		return r
+	}, decoded)
+}
@@ -0,0 +1,202 @@
+package searcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func init() {
+	Register(Bing{})
+}
+
+// Bing searches Bing Images with a headless browser
+type Bing struct{}
+
+// bingScroll counts the result anchors on the page and clicks "See more images"
+var bingScroll = scrollStrategy{
+	countJS: `document.querySelectorAll('a.iusc').length`,
+	moreJS:  `(() => { const b = document.querySelector('a.btn_seemore, .mm_seemore a') || Array.from(document.querySelectorAll('a, [role="button"]')).find(e => /^\s*see more images\s*$/i.test(e.textContent)); if (b && b.offsetParent !== null) { b.click(); return true; } return false; })()`,
+}
+
+// bingExtractJS returns the full-size URL of every result with the "1920 x 1080 · jpeg" size caption shown under it
+const bingExtractJS = `Array.from(document.querySelectorAll('a.iusc')).map(a => {
+	const info = a.closest('li') && a.closest('li').querySelector('.img_info .nowrap');
+	return {url: JSON.parse(a.getAttribute('m')).murl, info: info ? info.textContent : ''};
+})`
+
+// bingSizePattern matches the dimensions in a Bing result's size caption
+var bingSizePattern = regexp.MustCompile(`(\d+)\s*[x×]\s*(\d+)`)
+
+// bingResult is a result extracted from the Bing result page
+type bingResult struct {
+	URL  string `json:"url"`
+	Info string `json:"info"`
+}
+
+// Name returns the engine name
+func (Bing) Name() string {
+	return "bing"
+}
+
+// UsesBrowser reports that the engine drives a browser
+func (Bing) UsesBrowser() bool {
+	return true
+}
+
+// SearchURL returns the Bing image search page URL for the query, with the filters in opts as the qft parameter
+// and opts.Language and opts.Region as the market
+func (Bing) SearchURL(query string, opts Options) string {
+	searchURL := fmt.Sprintf("https://www.bing.com/images/search?q=%s", url.QueryEscape(filteredQuery(query, opts)))
+	if opts.SafeSearch != "" {
+		searchURL += "&adlt=" + opts.SafeSearch
+	}
+	// Bing takes a market when both the language and the country are known
+	if market := bingMarket(opts); market != "" {
+		searchURL += "&mkt=" + url.QueryEscape(market)
+	} else if opts.Language != "" {
+		searchURL += "&setlang=" + url.QueryEscape(opts.Language)
+	} else if opts.Region != "" {
+		searchURL += "&cc=" + url.QueryEscape(opts.Region)
+	}
+	if qft := bingQFT(opts); qft != "" {
+		searchURL += "&qft=" + qft + "&form=IRFLTR"
+	}
+	return searchURL
+}
+
+// Search searches for images on Bing using chromedp and returns the image URLs, or with opts.NoBrowser
+// through SearchWithoutBrowser
+func (b Bing) Search(ctx context.Context, query string, opts Options) ([]Result, error) {
+	if opts.NoBrowser {
+		return b.SearchWithoutBrowser(ctx, query, opts)
+	}
+	var extracted []bingResult
+	searchURL := b.SearchURL(query, opts)
+
+	tab, err := openTab(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer tab.Close()
+
+	// Load the Bing image search page, scroll down to load more images (simulate user interaction) and extract them
+	err = loadResultsPage(ctx, tab, b.Name(), searchURL, opts)
+	if err == nil {
+		err = scrollPage(ctx, tab, bingScroll, 5, opts)
+	}
+	if err == nil {
+		err = tab.Evaluate(bingExtractJS, &extracted)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch Bing images: %w", err)
+	}
+
+	if captured := capturedResults(tab, b.Name(), query, searchURL); captured != nil {
+		return LimitResults(captured, opts.Limit), nil
+	}
+
+	// Keep the dimensions from the size captions so undersized images can be skipped without downloading them
+	var results []Result
+	for _, r := range extracted {
+		if r.URL == "" {
+			continue
+		}
+		result := Result{URL: r.URL, PageURL: searchURL, Engine: b.Name(), Query: query}
+		if match := bingSizePattern.FindStringSubmatch(r.Info); match != nil {
+			result.Width, _ = strconv.Atoi(match[1])
+			result.Height, _ = strconv.Atoi(match[2])
+		}
+		results = append(results, result)
+	}
+
+	return LimitResults(results, opts.Limit), nil
+}
+
+// bingPageSize is how many results a page of Bing's async results endpoint holds
+const bingPageSize = 35
+
+// bingMetadata is the JSON in the m attribute of a Bing result anchor
+type bingMetadata struct {
+	URL   string `json:"murl"`
+	Title string `json:"t"`
+}
+
+// SearchWithoutBrowser searches Bing Images through the plain HTML of its results page and the async endpoint
+// behind its infinite scroll, both of which embed the original image URLs in the result anchors
+func (b Bing) SearchWithoutBrowser(ctx context.Context, query string, opts Options) ([]Result, error) {
+	searchURL := b.SearchURL(query, opts)
+	header := http.Header{}
+	if acceptLanguage := AcceptLanguage(opts); acceptLanguage != "" {
+		header.Set("Accept-Language", acceptLanguage)
+	}
+
+	var results []Result
+	seen := make(map[string]bool)
+	for page := 0; page < maxPages(opts); page++ {
+		pageURL := searchURL
+		if page > 0 {
+			pageURL = strings.Replace(searchURL, "/images/search?", "/images/async?", 1) + fmt.Sprintf("&first=%d&count=%d", page*bingPageSize+1, bingPageSize)
+		}
+		body, err := fetch(ctx, opts, pageURL, header)
+		if err != nil {
+			if page > 0 {
+				// Keep the results of the pages fetched so far
+				slog.Warn("Failed to fetch more Bing results", "page", page, "error", err)
+				break
+			}
+			return nil, fmt.Errorf("failed to fetch Bing images: %w", err)
+		}
+
+		added := 0
+		for _, result := range parseBingResults(string(body), b.Name(), query, searchURL) {
+			if !seen[result.URL] {
+				seen[result.URL] = true
+				results = append(results, result)
+				added++
+			}
+		}
+		slog.Debug("Fetched Bing results page", "page", page, "results", added)
+		if added == 0 || (opts.Limit > 0 && len(results) >= opts.Limit) {
+			break
+		}
+	}
+
+	return LimitResults(results, opts.Limit), nil
+}
+
+// parseBingResults extracts the original image URL, title and size caption of every result anchor in Bing HTML.
+// The anchors carry the result's metadata as JSON in their m attribute, and the size caption, e.g.
+// "1920 x 1080 · jpeg", follows them in the same result container.
+func parseBingResults(page, engine, query, pageURL string) []Result {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(page))
+	if err != nil {
+		return nil
+	}
+
+	var results []Result
+	doc.Find("a.iusc[m]").Each(func(_ int, anchor *goquery.Selection) {
+		var metadata bingMetadata
+		if err := json.Unmarshal([]byte(anchor.AttrOr("m", "")), &metadata); err != nil || !strings.HasPrefix(metadata.URL, "http") {
+			return
+		}
+
+		result := Result{URL: metadata.URL, PageURL: pageURL, Engine: engine, Query: query, Title: html.UnescapeString(metadata.Title)}
+		caption := anchor.Closest(".imgpt, li").Find(".nowrap").First().Text()
+		if size := bingSizePattern.FindStringSubmatch(caption); size != nil {
+			result.Width, _ = strconv.Atoi(size[1])
+			result.Height, _ = strconv.Atoi(size[2])
+		}
+		results = append(results, result)
+	})
+	return results
+}
@@ -0,0 +1,28 @@
+package searcher
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseBingResults(t *testing.T) {
+	page := `<ul class="dgControl_list">
+<li><div class="iuscp"><div class="imgpt">
+	<a class="iusc" m="{&quot;murl&quot;:&quot;https://example.com/cat.jpg&quot;,&quot;t&quot;:&quot;Cats &amp;amp; dogs&quot;}" href="/images/search?view=detailV2"><img src="thumb"></a>
+	<div class="img_info hon"><span class="nowrap">1920 x 1080 · jpeg</span></div>
+</div></div></li>
+<li><div class="iuscp"><div class="imgpt">
+	<a class="iusc" m="{&quot;murl&quot;:&quot;/relative.jpg&quot;}"></a>
+</div></div></li>
+<li><div class="iuscp"><div class="imgpt">
+	<a class="iusc" m='{"murl":"https://example.com/dog.png","t":"Dog"}'></a>
+</div></div></li>
+</ul>`
+	want := []Result{
+		{URL: "https://example.com/cat.jpg", PageURL: "https://www.bing.com/images/search?q=cats", Engine: "bing", Query: "cats", Title: "Cats & dogs", Width: 1920, Height: 1080},
+		{URL: "https://example.com/dog.png", PageURL: "https://www.bing.com/images/search?q=cats", Engine: "bing", Query: "cats", Title: "Dog"},
+	}
+	if got := parseBingResults(page, "bing", "cats", "https://www.bing.com/images/search?q=cats"); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
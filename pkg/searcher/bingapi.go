@@ -0,0 +1,98 @@
+package searcher
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+func init() {
+	Register(BingAPI{})
+}
+
+// bingAPIPageSize is the largest page the Bing Image Search API returns
+const bingAPIPageSize = 150
+
+// BingAPI searches Bing Images through the Bing Image Search REST API.
+// It needs a subscription key, set as the "bing-api" credential or the BING_API_KEY environment variable.
+type BingAPI struct{}
+
+// Name returns the engine name
+func (BingAPI) Name() string {
+	return "bing-api"
+}
+
+// SearchURL returns the Bing Image Search API URL for the query and result offset
+func (BingAPI) SearchURL(query string, offset int) string {
+	return "https://api.bing.microsoft.com/v7.0/images/search?" + url.Values{
+		"q":      {query},
+		"count":  {strconv.Itoa(bingAPIPageSize)},
+		"offset": {strconv.Itoa(offset)},
+	}.Encode()
+}
+
+// bingAPIResponse is the response of the images/search endpoint
+type bingAPIResponse struct {
+	Value []struct {
+		ContentURL     string `json:"contentUrl"`
+		HostPageURL    string `json:"hostPageUrl"`
+		Name           string `json:"name"`
+		Width          int    `json:"width"`
+		Height         int    `json:"height"`
+		EncodingFormat string `json:"encodingFormat"`
+	} `json:"value"`
+	NextOffset            int `json:"nextOffset"`
+	TotalEstimatedMatches int `json:"totalEstimatedMatches"`
+}
+
+// Search pages through the Bing Image Search API results
+func (b BingAPI) Search(ctx context.Context, query string, opts Options) ([]Result, error) {
+	key := opts.credential("bing-api", "BING_API_KEY")
+	if key == "" {
+		return nil, fmt.Errorf("bing-api needs an API key: set BING_API_KEY or pass the bing-api credential")
+	}
+	header := http.Header{"Ocp-Apim-Subscription-Key": {key}}
+
+	var results []Result
+	offset := 0
+	for page := 0; page < maxPages(opts); page++ {
+		if opts.Limit > 0 && len(results) >= opts.Limit {
+			break
+		}
+
+		var resp bingAPIResponse
+		if err := fetchJSON(ctx, opts, b.SearchURL(query, offset), header, &resp); err != nil {
+			return nil, fmt.Errorf("failed to fetch Bing API images: %v", err)
+		}
+
+		for _, v := range resp.Value {
+			results = append(results, Result{
+				URL:         v.ContentURL,
+				PageURL:     v.HostPageURL,
+				Engine:      b.Name(),
+				Query:       query,
+				Title:       v.Name,
+				Width:       v.Width,
+				Height:      v.Height,
+				ContentType: mimeFromFormat(v.EncodingFormat),
+			})
+		}
+
+		if len(resp.Value) == 0 || resp.NextOffset <= offset || resp.NextOffset >= resp.TotalEstimatedMatches {
+			break
+		}
+		offset = resp.NextOffset
+	}
+
+	return LimitResults(results, opts.Limit), nil
+}
+
+// mimeFromFormat turns a bare format name like "jpeg" into a MIME type
+func mimeFromFormat(format string) string {
+	if format == "" {
+		return ""
+	}
+	return "image/" + format
+}
@@ -0,0 +1,68 @@
+package searcher
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+func init() {
+	Register(Brave{})
+}
+
+// bravePageSize is the largest number of images the Brave image search API returns; it has no pagination
+const bravePageSize = 100
+
+// Brave searches images through the Brave Search API.
+// It needs a subscription token, set as the "brave" credential or the BRAVE_API_KEY environment variable.
+type Brave struct{}
+
+// Name returns the engine name
+func (Brave) Name() string {
+	return "brave"
+}
+
+// SearchURL returns the images/search URL for the query
+func (Brave) SearchURL(query string) string {
+	return "https://api.search.brave.com/res/v1/images/search?" + url.Values{
+		"q":     {query},
+		"count": {strconv.Itoa(bravePageSize)},
+	}.Encode()
+}
+
+// braveResponse is the response of the images/search endpoint
+type braveResponse struct {
+	Results []struct {
+		Title      string `json:"title"`
+		URL        string `json:"url"`
+		Properties struct {
+			URL string `json:"url"`
+		} `json:"properties"`
+	} `json:"results"`
+}
+
+// Search fetches the Brave image search results
+func (b Brave) Search(ctx context.Context, query string, opts Options) ([]Result, error) {
+	key := opts.credential("brave", "BRAVE_API_KEY")
+	if key == "" {
+		return nil, fmt.Errorf("brave needs an API key: set BRAVE_API_KEY or pass the brave credential")
+	}
+	header := http.Header{"X-Subscription-Token": {key}, "Accept": {"application/json"}}
+
+	var resp braveResponse
+	if err := fetchJSON(ctx, opts, b.SearchURL(query), header, &resp); err != nil {
+		return nil, fmt.Errorf("failed to fetch Brave images: %v", err)
+	}
+
+	var results []Result
+	for _, r := range resp.Results {
+		if r.Properties.URL == "" {
+			continue
+		}
+		results = append(results, Result{URL: r.Properties.URL, PageURL: r.URL, Engine: b.Name(), Query: query, Title: r.Title})
+	}
+
+	return LimitResults(results, opts.Limit), nil
+}
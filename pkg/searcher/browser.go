@@ -0,0 +1,429 @@
+package searcher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/target"
+	"github.com/chromedp/chromedp"
+)
+
+// ErrNoBrowser is returned by browser-based searches with Options.NoBrowser
+var ErrNoBrowser = errors.New("no browser available, the engine needs Chrome")
+
+// BrowserAvailable reports whether a browser can be used with the options: opts.Browser.RemoteBrowser is set, opts.Browser.ChromePath
+// exists, or Chrome is found where chromedp looks for it. Other backends are trusted to find their browser.
+func BrowserAvailable(opts Options) bool {
+	if opts.Browser.RemoteBrowser != "" || (opts.Browser.Backend != "" && opts.Browser.Backend != DefaultBackend) {
+		return true
+	}
+	if opts.Browser.ChromePath != "" {
+		_, err := exec.LookPath(opts.Browser.ChromePath)
+		return err == nil
+	}
+
+	var locations []string
+	switch runtime.GOOS {
+	case "darwin":
+		locations = []string{
+			"/Applications/Chromium.app/Contents/MacOS/Chromium",
+			"/Applications/Google Chrome.app/Contents/MacOS/Google Chrome",
+		}
+	case "windows":
+		locations = []string{
+			"chrome",
+			"chrome.exe",
+			`C:\Program Files (x86)\Google\Chrome\Application\chrome.exe`,
+			`C:\Program Files\Google\Chrome\Application\chrome.exe`,
+			filepath.Join(os.Getenv("USERPROFILE"), `AppData\Local\Google\Chrome\Application\chrome.exe`),
+			filepath.Join(os.Getenv("USERPROFILE"), `AppData\Local\Chromium\Application\chrome.exe`),
+		}
+	default:
+		locations = []string{
+			"headless_shell",
+			"headless-shell",
+			"chromium",
+			"chromium-browser",
+			"google-chrome",
+			"google-chrome-stable",
+			"google-chrome-beta",
+			"google-chrome-unstable",
+			"/usr/bin/google-chrome",
+			"/usr/local/bin/chrome",
+			"/snap/bin/chromium",
+			"chrome",
+		}
+	}
+	for _, location := range locations {
+		if _, err := exec.LookPath(location); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// sharedProxyKey is the context key of the proxy a browser started by NewBrowser uses
+type sharedProxyKey struct{}
+
+// NewBrowser starts a browser to share between searches with the backend of opts.Browser.Backend. Searches given the
+// returned context, or one derived from it, open a tab in the browser instead of starting their own. The returned
+// cancel function shuts the browser down.
+func NewBrowser(ctx context.Context, opts Options) (context.Context, context.CancelFunc, error) {
+	if opts.NoBrowser {
+		return nil, nil, ErrNoBrowser
+	}
+	b, err := backend(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return b.NewBrowser(ctx, opts)
+}
+
+// newChromedpBrowser starts the shared browser of chromedpBackend, using opts.Browser.UserDataDir, opts.Proxy and
+// opts.Browser.RemoteBrowser like NewBrowserContext. A search through a proxy other than opts.Proxy gets its tab in a
+// separate browser context using that proxy.
+func newChromedpBrowser(ctx context.Context, opts Options) (context.Context, context.CancelFunc, error) {
+	browserCtx, cancel := newBrowser(ctx, opts)
+	if err := chromedp.Run(browserCtx); err != nil {
+		cancel()
+		return nil, nil, fmt.Errorf("failed to start the browser: %v", err)
+	}
+	return context.WithValue(browserCtx, sharedProxyKey{}, opts.Proxy), cancel, nil
+}
+
+// NewBrowserContext returns a ChromeDP context for a browser-based search.
+// When ctx already carries a ChromeDP browser a new tab is opened in it, and with opts.Browser.RemoteBrowser set a tab is opened in that browser.
+// Otherwise a new headless Chrome instance is started, using opts.Browser.UserDataDir as its profile and opts.Proxy as its proxy server if set.
+// With opts.Browser.Stealth the tab is disguised as a regular Chrome, and the viewport, locale and timezone of the options are emulated.
+// Cookies from opts.Browser.CookieFile are loaded before any page is opened, and pages are requested in opts.Language through the Accept-Language header.
+// With opts.Browser.CaptureImages the images the tab loads are collected from its network traffic.
+// The returned cancel function closes the tab or shuts the browser down, leaving a remote browser running.
+// With opts.NoBrowser it fails with ErrNoBrowser.
+func NewBrowserContext(ctx context.Context, opts Options) (context.Context, context.CancelFunc, error) {
+	if opts.NoBrowser {
+		return nil, nil, ErrNoBrowser
+	}
+	var taskCtx context.Context
+	var cancel context.CancelFunc
+	if chromedp.FromContext(ctx) != nil {
+		var tabOpts []chromedp.ContextOption
+		if proxy, _ := ctx.Value(sharedProxyKey{}).(string); opts.Proxy != proxy {
+			// The browser uses another proxy, so open the tab in a browser context of its own using this one
+			tabOpts = append(tabOpts, chromedp.WithNewBrowserContext(func(p *target.CreateBrowserContextParams) *target.CreateBrowserContextParams {
+				return p.WithProxyServer(chromeProxy(opts.Proxy))
+			}))
+		}
+		taskCtx, cancel = chromedp.NewContext(ctx, tabOpts...)
+	} else {
+		taskCtx, cancel = newBrowser(ctx, opts)
+	}
+
+	if opts.Browser.Stealth {
+		if err := chromedp.Run(taskCtx, stealth(opts)); err != nil {
+			cancel()
+			return nil, nil, fmt.Errorf("failed to disguise the browser: %v", err)
+		}
+	}
+
+	if err := chromedp.Run(taskCtx, emulate(opts)); err != nil {
+		cancel()
+		return nil, nil, err
+	}
+
+	if acceptLanguage := AcceptLanguage(opts); acceptLanguage != "" {
+		err := chromedp.Run(taskCtx, network.Enable(), network.SetExtraHTTPHeaders(network.Headers{"Accept-Language": acceptLanguage}))
+		if err != nil {
+			cancel()
+			return nil, nil, fmt.Errorf("failed to set the browser language: %v", err)
+		}
+	}
+
+	if opts.Browser.CookieFile != "" {
+		cookies, err := loadCookies(opts.Browser.CookieFile)
+		if err != nil {
+			cancel()
+			return nil, nil, err
+		}
+		if err := chromedp.Run(taskCtx, network.SetCookies(cookies)); err != nil {
+			cancel()
+			return nil, nil, fmt.Errorf("failed to set cookies: %v", err)
+		}
+	}
+
+	if opts.Browser.CaptureImages {
+		capture := captureImages(taskCtx)
+		if err := chromedp.Run(taskCtx, network.Enable()); err != nil {
+			cancel()
+			return nil, nil, fmt.Errorf("failed to capture images: %v", err)
+		}
+		taskCtx = context.WithValue(taskCtx, imageCaptureKey{}, capture)
+	}
+
+	return taskCtx, cancel, nil
+}
+
+// newBrowser returns a ChromeDP context for a new headless Chrome instance, or for the remote browser of
+// opts.Browser.RemoteBrowser. The browser is only started or connected to by the first action run in the context.
+func newBrowser(ctx context.Context, opts Options) (context.Context, context.CancelFunc) {
+	var allocCtx context.Context
+	var cancelAlloc context.CancelFunc
+	if opts.Browser.RemoteBrowser != "" {
+		// Attach to the remote browser, cancelling only closes the tab
+		slog.Debug("Connecting to remote browser", "url", opts.Browser.RemoteBrowser)
+		allocCtx, cancelAlloc = chromedp.NewRemoteAllocator(ctx, opts.Browser.RemoteBrowser)
+	} else {
+		// Start a new ChromeDP instance
+		allocOpts := append(chromedp.DefaultExecAllocatorOptions[:], chromedp.Flag("headless", true))
+		if opts.Browser.UserDataDir != "" {
+			allocOpts = append(allocOpts, chromedp.UserDataDir(opts.Browser.UserDataDir))
+		}
+		if opts.Proxy != "" {
+			allocOpts = append(allocOpts, chromedp.ProxyServer(chromeProxy(opts.Proxy)))
+		}
+		if opts.Browser.Stealth {
+			allocOpts = append(allocOpts, stealthFlags...)
+		}
+		if opts.Browser.Headful {
+			allocOpts = append(allocOpts, chromedp.Flag("headless", false), chromedp.Flag("hide-scrollbars", false), chromedp.Flag("mute-audio", false))
+		}
+		if opts.Browser.ChromePath != "" {
+			allocOpts = append(allocOpts, chromedp.ExecPath(opts.Browser.ChromePath))
+		}
+		for name, value := range opts.Browser.ChromeFlags {
+			allocOpts = append(allocOpts, chromedp.Flag(name, value))
+		}
+		slog.Debug("Starting browser", "path", opts.Browser.ChromePath, "flags", opts.Browser.ChromeFlags, "user_data_dir", opts.Browser.UserDataDir, "proxy", opts.Proxy != "")
+		allocCtx, cancelAlloc = chromedp.NewExecAllocator(ctx, allocOpts...)
+	}
+
+	// Create a new ChromeDP context, sending its messages to the default logger
+	taskCtx, cancelTask := chromedp.NewContext(allocCtx,
+		chromedp.WithLogf(func(format string, args ...any) { slog.Debug(fmt.Sprintf(format, args...), "source", "chromedp") }),
+		chromedp.WithErrorf(func(format string, args ...any) { slog.Warn(fmt.Sprintf(format, args...), "source", "chromedp") }),
+	)
+	return taskCtx, func() {
+		cancelTask()
+		cancelAlloc()
+	}
+}
+
+// chromeProxy converts a proxy URL to Chrome's --proxy-server form. Chrome takes no proxy credentials and
+// always resolves host names through a SOCKS5 proxy, so it only understands the socks5 scheme.
+func chromeProxy(proxy string) string {
+	proxyURL, err := url.Parse(proxy)
+	if err != nil {
+		return proxy
+	}
+	proxyURL.User = nil
+	if proxyURL.Scheme == "socks5h" {
+		proxyURL.Scheme = "socks5"
+	}
+	return proxyURL.String()
+}
+
+// exportedCookie is a cookie as written by browser cookie export extensions and DevTools
+type exportedCookie struct {
+	Name           string  `json:"name"`
+	Value          string  `json:"value"`
+	Domain         string  `json:"domain"`
+	Path           string  `json:"path"`
+	Expires        float64 `json:"expires"`
+	ExpirationDate float64 `json:"expirationDate"`
+	Secure         bool    `json:"secure"`
+	HTTPOnly       bool    `json:"httpOnly"`
+}
+
+// loadCookies reads a JSON array of exported cookies or a Netscape cookies.txt file, as written by curl, wget
+// and the "cookies.txt" browser extensions
+func loadCookies(path string) ([]*network.CookieParam, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cookie file: %v", err)
+	}
+	if !strings.HasPrefix(strings.TrimSpace(string(data)), "[") {
+		return parseNetscapeCookies(string(data))
+	}
+
+	var exported []exportedCookie
+	if err := json.Unmarshal(data, &exported); err != nil {
+		return nil, fmt.Errorf("failed to parse cookie file: %v", err)
+	}
+
+	cookies := make([]*network.CookieParam, 0, len(exported))
+	for _, c := range exported {
+		cookie := &network.CookieParam{
+			Name:     c.Name,
+			Value:    c.Value,
+			Domain:   c.Domain,
+			Path:     c.Path,
+			Secure:   c.Secure,
+			HTTPOnly: c.HTTPOnly,
+		}
+		if expires := max(c.Expires, c.ExpirationDate); expires > 0 {
+			t := cdp.TimeSinceEpoch(time.Unix(int64(expires), 0))
+			cookie.Expires = &t
+		}
+		cookies = append(cookies, cookie)
+	}
+	return cookies, nil
+}
+
+// parseNetscapeCookies parses the tab-separated lines of a cookies.txt file: domain, whether subdomains match,
+// path, secure, expiry as Unix time, name and value. Lines starting with #HttpOnly_ hold HTTP-only cookies,
+// other lines starting with # are comments.
+func parseNetscapeCookies(data string) ([]*network.CookieParam, error) {
+	var cookies []*network.CookieParam
+	for i, line := range strings.Split(data, "\n") {
+		line = strings.TrimRight(line, "\r")
+		httpOnly := strings.HasPrefix(line, "#HttpOnly_")
+		if httpOnly {
+			line = strings.TrimPrefix(line, "#HttpOnly_")
+		}
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("failed to parse cookie file: line %d has %d tab-separated fields instead of 7", i+1, len(fields))
+		}
+		domain, subdomains, path, secure, expires, name, value := fields[0], fields[1], fields[2], fields[3], fields[4], fields[5], fields[6]
+		cookie := &network.CookieParam{
+			Name:     name,
+			Value:    value,
+			Path:     path,
+			Secure:   strings.EqualFold(secure, "TRUE"),
+			HTTPOnly: httpOnly,
+		}
+		if strings.EqualFold(subdomains, "TRUE") {
+			cookie.Domain = domain
+		} else {
+			// A host-only cookie is set through its URL, since a domain would also match the subdomains
+			scheme := "http"
+			if cookie.Secure {
+				scheme = "https"
+			}
+			cookie.URL = scheme + "://" + strings.TrimPrefix(domain, ".") + path
+		}
+		if seconds, err := strconv.ParseInt(expires, 10, 64); err == nil && seconds > 0 {
+			t := cdp.TimeSinceEpoch(time.Unix(seconds, 0))
+			cookie.Expires = &t
+		}
+		cookies = append(cookies, cookie)
+	}
+	return cookies, nil
+}
+
+// scrollStrategy describes how to tell how many results an engine's page shows and how to ask it for more
+type scrollStrategy struct {
+	countJS string // Expression returning the number of results on the page
+	moreJS  string // Expression clicking the engine's "show more results" control if it is visible, returning whether it did
+}
+
+// stalledScrolls is how many scrolls in a row may add no results before a paginated search gives up
+const stalledScrolls = 3
+
+// defaultScrollDelay is how long scrollPage waits for images to load after each scroll without opts.ScrollDelay
+const defaultScrollDelay = 500 * time.Millisecond
+
+// scrollPage scrolls to the bottom of the page the given number of times, or opts.ScrollCount times if set, waiting
+// opts.ScrollDelay for images to load after each scroll. When a scroll adds no results, "show more" is clicked in case
+// the infinite scroll stopped at it. When opts.Limit is set, scrolling stops early once the page shows at least that
+// many results. With opts.Paginate it keeps scrolling and clicking "show more" up to opts.MaxDepth times, until the
+// limit is met or the engine stops returning new results. It fails with the error of ctx once ctx is done.
+func scrollPage(ctx context.Context, tab BrowserTab, strategy scrollStrategy, times int, opts Options) error {
+	switch {
+	case opts.Paginate:
+		times = opts.MaxDepth
+	case opts.ScrollCount > 0:
+		times = opts.ScrollCount
+	}
+	delay := opts.ScrollDelay
+	if delay <= 0 {
+		delay = defaultScrollDelay
+	}
+
+	previous, stalled := -1, 0
+	for i := 0; i < times; i++ {
+		var count int
+		if err := tab.Evaluate(strategy.countJS, &count); err != nil {
+			return err
+		}
+		slog.Debug("Scrolled results page", "scroll", i, "results", count)
+		if opts.Limit > 0 && count >= opts.Limit {
+			return nil
+		}
+
+		if opts.Paginate {
+			// The engine ran out of results if neither scrolling nor "show more" added any
+			if count == previous {
+				stalled++
+				if stalled >= stalledScrolls {
+					return nil
+				}
+			} else {
+				stalled = 0
+			}
+		}
+
+		// Paginated searches click "show more" whenever it shows up, others only once scrolling stalls
+		if strategy.moreJS != "" && (opts.Paginate || count == previous) {
+			var clicked bool
+			if err := tab.Evaluate(strategy.moreJS, &clicked); err != nil {
+				return err
+			}
+			if clicked {
+				slog.Debug("Clicked the show more results button", "scroll", i, "results", count)
+			}
+		}
+		previous = count
+
+		if err := tab.Scroll(); err != nil {
+			return err
+		}
+		// Wait for images to load after each scroll
+		if err := sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseEmbeddedURLs extracts the unique image URLs captured by pattern from a page source, for engines that
+// embed their result list as JSON. decode, if set, is applied to every URL after unescaping.
+func parseEmbeddedURLs(html string, pattern *regexp.Regexp, decode func(string) string) []string {
+	var imageURLs []string
+	seen := make(map[string]bool)
+	for _, match := range pattern.FindAllStringSubmatch(html, -1) {
+		// Values in the embedded JSON are string literals that may contain escapes like \/
+		imageURL := match[1]
+		var unquoted string
+		if err := json.Unmarshal([]byte(`"`+imageURL+`"`), &unquoted); err == nil {
+			imageURL = unquoted
+		}
+
+		if decode != nil {
+			imageURL = decode(imageURL)
+		}
+		if !strings.HasPrefix(imageURL, "http") || seen[imageURL] {
+			continue
+		}
+		seen[imageURL] = true
+		imageURLs = append(imageURLs, imageURL)
+	}
+	return imageURLs
+}
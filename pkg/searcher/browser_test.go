@@ -0,0 +1,22 @@
+package searcher
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestScrollPageStopsWhenCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	tab := &fakeTab{values: map[string]string{"count": "1"}}
+	opts := Options{ScrollCount: 10, ScrollDelay: time.Hour}
+
+	if err := scrollPage(ctx, tab, scrollStrategy{countJS: "count"}, 5, opts); !errors.Is(err, context.Canceled) {
+		t.Fatalf("got error %v, want context.Canceled", err)
+	}
+	if tab.scrolls != 1 {
+		t.Errorf("scrolled %d times, want 1", tab.scrolls)
+	}
+}
@@ -0,0 +1,205 @@
+package searcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ChallengeError is returned by a browser-based search that ran into a CAPTCHA or consent page instead of results
+// and couldn't get past it
+type ChallengeError struct {
+	Engine    string
+	Challenge string // What the page asked for, e.g. "Google CAPTCHA", "Yandex CAPTCHA" or "consent"
+	URL       string // Address of the challenge page
+}
+
+func (e *ChallengeError) Error() string {
+	return fmt.Sprintf("%s showed a %s page instead of results", e.Engine, e.Challenge)
+}
+
+// captchaServices are the 2captcha-compatible solving services Options.Browser.CaptchaSolver may name instead of a URL
+var captchaServices = map[string]string{
+	"2captcha":  "https://2captcha.com",
+	"rucaptcha": "https://rucaptcha.com",
+}
+
+// captchaWidget is a token-based CAPTCHA found on a challenge page
+type captchaWidget struct {
+	Kind     string `json:"kind"`     // "recaptcha" or "hcaptcha"
+	SiteKey  string `json:"siteKey"`  // Public key of the site, sent to the solving service
+	Data     string `json:"data"`     // Google's data-s value, which its sorry page needs solved along with the key
+	Callback string `json:"callback"` // Function the page calls with the token, empty to submit the form
+}
+
+// captchaWidgetJS is an expression returning the captchaWidget of the page, or null if it has none
+const captchaWidgetJS = `(() => {
+	const element = document.querySelector('[data-sitekey]');
+	if (element) {
+		return {
+			kind: element.classList.contains('h-captcha') ? 'hcaptcha' : 'recaptcha',
+			siteKey: element.dataset.sitekey,
+			data: element.dataset.s || '',
+			callback: element.dataset.callback || '',
+		};
+	}
+	for (const frame of document.querySelectorAll('iframe[src*="recaptcha"], iframe[src*="hcaptcha"]')) {
+		const src = new URL(frame.src, location.href);
+		const siteKey = src.searchParams.get('k') || src.searchParams.get('sitekey') || new URLSearchParams(src.hash.slice(1)).get('sitekey');
+		if (siteKey) {
+			return { kind: frame.src.includes('hcaptcha') ? 'hcaptcha' : 'recaptcha', siteKey, data: '', callback: '' };
+		}
+	}
+	return null;
+})()`
+
+// captchaSubmitJS fills the token of a solved captchaWidget into the page and submits it. It is formatted with
+// the JSON-encoded token, kind and callback.
+const captchaSubmitJS = `((token, kind, callback) => {
+	const name = kind === 'hcaptcha' ? 'h-captcha-response' : 'g-recaptcha-response';
+	const fields = document.querySelectorAll('[name="' + name + '"], #' + name);
+	for (const field of fields) {
+		field.value = token;
+		field.innerHTML = token;
+	}
+	if (callback && typeof window[callback] === 'function') {
+		window[callback](token);
+		return true;
+	}
+	const form = (fields[0] && fields[0].closest('form')) || document.querySelector('form');
+	if (form) {
+		form.submit();
+		return true;
+	}
+	return false;
+})(%s, %s, %s)`
+
+// yandexCheckboxJS clicks the "I'm not a robot" checkbox of Yandex's CAPTCHA page, returning whether it found it
+const yandexCheckboxJS = `(() => {
+	const button = document.querySelector('.CheckboxCaptcha-Button, .CheckboxCaptcha input[type="submit"], #js-button');
+	if (!button) {
+		return false;
+	}
+	button.click();
+	return true;
+})()`
+
+// solveChallenge tries to get past a challenge page without the user: it ticks Yandex's checkbox CAPTCHA, and
+// has token-based CAPTCHAs solved by the service of opts.Browser.CaptchaSolver if set. It reports whether the page is gone.
+func solveChallenge(ctx context.Context, tab BrowserTab, engine, challenge string, opts Options) (bool, error) {
+	if challenge == "Yandex CAPTCHA" {
+		var clicked bool
+		if err := tab.Evaluate(yandexCheckboxJS, &clicked); err != nil {
+			return false, err
+		}
+		if clicked {
+			slog.Debug("Ticked the Yandex CAPTCHA checkbox", "engine", engine)
+			if solved, err := waitForChallenge(ctx, tab, 10*time.Second); solved || err != nil {
+				return solved, err
+			}
+		}
+	}
+	if opts.Browser.CaptchaSolver == "" {
+		return false, nil
+	}
+
+	// The page has no widget when the expression returns null
+	var widget *captchaWidget
+	if err := tab.Evaluate(captchaWidgetJS, &widget); err != nil {
+		return false, err
+	}
+	if widget == nil || widget.SiteKey == "" {
+		slog.Warn("The challenge page has no CAPTCHA the solving service can solve", "engine", engine, "challenge", challenge)
+		return false, nil
+	}
+	pageURL, err := tab.Location()
+	if err != nil {
+		return false, err
+	}
+
+	slog.Info("Sending the CAPTCHA to the solving service", "engine", engine, "kind", widget.Kind)
+	token, err := solveCaptcha(ctx, opts, *widget, pageURL)
+	if err != nil {
+		return false, fmt.Errorf("failed to solve the %s: %w", challenge, err)
+	}
+	tokenJSON, _ := json.Marshal(token)
+	kindJSON, _ := json.Marshal(widget.Kind)
+	callbackJSON, _ := json.Marshal(widget.Callback)
+	var submitted bool
+	if err := tab.Evaluate(fmt.Sprintf(captchaSubmitJS, tokenJSON, kindJSON, callbackJSON), &submitted); err != nil {
+		return false, err
+	}
+	if !submitted {
+		return false, fmt.Errorf("failed to submit the solved %s", challenge)
+	}
+	return waitForChallenge(ctx, tab, 30*time.Second)
+}
+
+// captchaResponse is the reply of a 2captcha-compatible service to submitting a CAPTCHA or polling for its token
+type captchaResponse struct {
+	Status  int    `json:"status"`
+	Request string `json:"request"` // Task ID or token if Status is 1, otherwise the error code
+}
+
+// solveCaptcha submits the CAPTCHA to the 2captcha-compatible service of opts.Browser.CaptchaSolver and waits for its token.
+// The API key is the "captcha" credential or the CAPTCHA_API_KEY environment variable.
+func solveCaptcha(ctx context.Context, opts Options, widget captchaWidget, pageURL string) (string, error) {
+	service := opts.Browser.CaptchaSolver
+	if known, ok := captchaServices[strings.ToLower(service)]; ok {
+		service = known
+	}
+	service = strings.TrimRight(service, "/")
+	key := opts.credential("captcha", "CAPTCHA_API_KEY")
+	if key == "" {
+		return "", fmt.Errorf("no API key for the solving service, set the captcha credential or CAPTCHA_API_KEY")
+	}
+	// The service is reached directly, since the search proxy isn't meant for it
+	direct := Options{}
+
+	form := url.Values{"key": {key}, "pageurl": {pageURL}, "json": {"1"}}
+	switch widget.Kind {
+	case "hcaptcha":
+		form.Set("method", "hcaptcha")
+		form.Set("sitekey", widget.SiteKey)
+	default:
+		form.Set("method", "userrecaptcha")
+		form.Set("googlekey", widget.SiteKey)
+		if widget.Data != "" {
+			form.Set("data-s", widget.Data)
+		}
+	}
+	var task captchaResponse
+	if err := postFormJSON(ctx, direct, service+"/in.php", form, &task); err != nil {
+		return "", err
+	}
+	if task.Status != 1 {
+		return "", fmt.Errorf("the solving service refused the CAPTCHA: %s", task.Request)
+	}
+
+	// Solving takes 15 to 45 seconds, so the first poll waits longer than the following ones
+	result := service + "/res.php?" + url.Values{"key": {key}, "action": {"get"}, "id": {task.Request}, "json": {"1"}}.Encode()
+	wait := 15 * time.Second
+	for {
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+		wait = 5 * time.Second
+
+		var answer captchaResponse
+		if err := fetchJSON(ctx, direct, result, nil, &answer); err != nil {
+			return "", err
+		}
+		switch {
+		case answer.Status == 1:
+			return answer.Request, nil
+		case answer.Request != "CAPCHA_NOT_READY":
+			return "", fmt.Errorf("the solving service failed: %s", answer.Request)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package searcher
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestSolveChallengeWithoutWidget(t *testing.T) {
+	// A consent page has no CAPTCHA, so the widget expression returns null
+	tab := &fakeTab{location: "https://consent.google.com/"}
+	opts := Options{Browser: BrowserOptions{CaptchaSolver: "2captcha"}}
+
+	solved, err := solveChallenge(context.Background(), tab, "google", "consent", opts)
+	if solved || err != nil {
+		t.Errorf("got %v, %v, want an unsolved page without an error", solved, err)
+	}
+}
+
+func TestSolveChallengeWithoutSolver(t *testing.T) {
+	tab := &fakeTab{}
+	solved, err := solveChallenge(context.Background(), tab, "google", "Google CAPTCHA", Options{})
+	if solved || err != nil || len(tab.evaluated) != 0 {
+		t.Errorf("got %v, %v and evaluated %d expressions, want nothing tried", solved, err, len(tab.evaluated))
+	}
+}
+
+func TestSolveChallengeWidgetWithoutKey(t *testing.T) {
+	tab := &fakeTab{values: map[string]string{captchaWidgetJS: `{"kind": "recaptcha", "siteKey": ""}`}}
+	opts := Options{Browser: BrowserOptions{CaptchaSolver: "2captcha"}}
+
+	solved, err := solveChallenge(context.Background(), tab, "google", "Google CAPTCHA", opts)
+	if solved || err != nil {
+		t.Errorf("got %v, %v, want an unsolved page without an error", solved, err)
+	}
+}
+
+func TestHandleChallengeWithoutWidget(t *testing.T) {
+	tab := &fakeTab{values: map[string]string{challengeJS: `"consent"`}, location: "https://consent.google.com/"}
+	opts := Options{Browser: BrowserOptions{CaptchaSolver: "2captcha"}}
+
+	err := handleChallenge(context.Background(), tab, "google", opts)
+	var challengeErr *ChallengeError
+	if !errors.As(err, &challengeErr) || challengeErr.Challenge != "consent" || challengeErr.URL != tab.location {
+		t.Errorf("got %v, want a ChallengeError for the consent page", err)
+	}
+}
@@ -0,0 +1,171 @@
+package searcher
+
+import (
+	"bytes"
+	"context"
+	"image"
+	_ "image/gif"  // Register the GIF decoder for the dimensions of captured images
+	_ "image/jpeg" // Register the JPEG decoder for the dimensions of captured images
+	_ "image/png"  // Register the PNG decoder for the dimensions of captured images
+	"log/slog"
+	"mime"
+	"strings"
+	"sync"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// imageCaptureKey is the context key of the imageCapture of a tab opened with Options.Browser.CaptureImages
+type imageCaptureKey struct{}
+
+const (
+	// minCapturedSide is the smallest width and height of a captured image that counts as a result, so icons,
+	// logos and sprites of the results page are left out
+	minCapturedSide = 100
+
+	// minCapturedBytes is the smallest captured image kept when its dimensions can't be read, e.g. a WebP
+	minCapturedBytes = 2048
+)
+
+// capturedImage is an image response the results page received
+type capturedImage struct {
+	url           string
+	contentType   string
+	data          []byte
+	width, height int
+}
+
+// imageCapture collects the images a tab loads from the DevTools network events
+type imageCapture struct {
+	mu      sync.Mutex
+	pending map[network.RequestID]*network.Response // Image responses whose body is still loading
+	images  []capturedImage                         // Captured images in the order they finished loading
+	seen    map[string]bool
+	stopped bool
+	fetches sync.WaitGroup
+}
+
+// captureImages starts collecting the image responses of the tab of ctx, which needs the network domain enabled
+func captureImages(ctx context.Context) *imageCapture {
+	capture := &imageCapture{pending: make(map[network.RequestID]*network.Response), seen: make(map[string]bool)}
+	chromedp.ListenTarget(ctx, func(ev any) {
+		capture.mu.Lock()
+		defer capture.mu.Unlock()
+		if capture.stopped {
+			return
+		}
+
+		switch ev := ev.(type) {
+		case *network.EventResponseReceived:
+			response := ev.Response
+			if ev.Type == network.ResourceTypeImage && response.Status == 200 && strings.HasPrefix(response.URL, "http") {
+				capture.pending[ev.RequestID] = response
+			}
+		case *network.EventLoadingFailed:
+			delete(capture.pending, ev.RequestID)
+		case *network.EventLoadingFinished:
+			response, ok := capture.pending[ev.RequestID]
+			if !ok {
+				return
+			}
+			delete(capture.pending, ev.RequestID)
+			if capture.seen[response.URL] {
+				return
+			}
+			capture.seen[response.URL] = true
+
+			// Listeners must not block, so the body is fetched from the browser in the background
+			capture.fetches.Add(1)
+			go func() {
+				defer capture.fetches.Done()
+				capture.fetch(ctx, ev.RequestID, response)
+			}()
+		}
+	})
+	return capture
+}
+
+// fetch reads the body of a finished image response from the browser and keeps it if it looks like a result
+func (c *imageCapture) fetch(ctx context.Context, requestID network.RequestID, response *network.Response) {
+	target := chromedp.FromContext(ctx).Target
+	if target == nil {
+		return
+	}
+	data, err := network.GetResponseBody(requestID).Do(cdp.WithExecutor(ctx, target))
+	if err != nil {
+		slog.Debug("Failed to read captured image", "url", response.URL, "error", err)
+		return
+	}
+
+	contentType, _, _ := mime.ParseMediaType(response.MimeType)
+	if contentType == "image/svg+xml" {
+		return
+	}
+	img := capturedImage{url: response.URL, contentType: contentType, data: data}
+	if config, _, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+		img.width, img.height = config.Width, config.Height
+		if img.width < minCapturedSide || img.height < minCapturedSide {
+			return
+		}
+	} else if len(data) < minCapturedBytes {
+		return
+	}
+
+	c.mu.Lock()
+	c.images = append(c.images, img)
+	c.mu.Unlock()
+}
+
+// stop ends the capture and returns the images captured, waiting for the bodies still being read
+func (c *imageCapture) stop() []capturedImage {
+	c.mu.Lock()
+	c.stopped = true
+	c.mu.Unlock()
+	c.fetches.Wait()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.images
+}
+
+// imageCapturer is implemented by browser tabs that capture the images their pages load with Options.Browser.CaptureImages.
+// capturedImages ends the capture and reports whether the tab captured images at all.
+type imageCapturer interface {
+	capturedImages() ([]capturedImage, bool)
+}
+
+// capturedResults returns the images the results page of the tab loaded with their contents, for a search with
+// Options.Browser.CaptureImages. It returns nil if the tab doesn't capture images or the page loaded none, so the
+// engine falls back to the image URLs it extracted.
+func capturedResults(tab BrowserTab, engine, query, pageURL string) []Result {
+	capturer, ok := tab.(imageCapturer)
+	if !ok {
+		return nil
+	}
+	images, capturing := capturer.capturedImages()
+	if !capturing {
+		return nil
+	}
+	if len(images) == 0 {
+		slog.Warn("The results page loaded no images to capture, falling back to the image URLs", "engine", engine, "query", query)
+		return nil
+	}
+
+	results := make([]Result, 0, len(images))
+	for _, img := range images {
+		results = append(results, Result{
+			URL:         img.url,
+			PageURL:     pageURL,
+			Engine:      engine,
+			Query:       query,
+			Width:       img.width,
+			Height:      img.height,
+			ContentType: img.contentType,
+			Data:        img.data,
+		})
+	}
+	slog.Debug("Captured images from the results page", "engine", engine, "query", query, "images", len(results))
+	return results
+}
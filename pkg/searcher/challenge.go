@@ -0,0 +1,105 @@
+package searcher
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// ChallengeWait is how long a search with Options.Browser.PauseOnChallenge waits for a challenge page to be solved.
+// Callers limiting the duration of a search should allow for it.
+const ChallengeWait = 5 * time.Minute
+
+// challengeJS is an expression returning what kind of challenge the page shows instead of results, e.g. a CAPTCHA
+// or a cookie consent screen, or an empty string for a regular page
+const challengeJS = `(() => {
+	const url = location.href;
+	const has = selector => document.querySelector(selector) !== null;
+	if (/\/sorry\//.test(url) && /google\./.test(location.hostname) || has('form[action*="/sorry/"]')) {
+		return 'Google CAPTCHA';
+	}
+	if (/^consent\./.test(location.hostname) || has('form[action*="consent.google."], form[action*="consent.yahoo."]')) {
+		return 'consent';
+	}
+	if (/showcaptcha|checkcaptcha/.test(url) || has('.CheckboxCaptcha, .AdvancedCaptcha, form[action*="checkcaptcha"]')) {
+		return 'Yandex CAPTCHA';
+	}
+	if (/wappass\.baidu\.com|\/captcha\//.test(url)) {
+		return 'Baidu CAPTCHA';
+	}
+	if (has('iframe[src*="recaptcha"], iframe[src*="hcaptcha"], iframe[src*="challenges.cloudflare.com"], #challenge-form')) {
+		return 'CAPTCHA';
+	}
+	return '';
+})()`
+
+// handleChallenge checks whether the engine answered with a challenge page instead of results in the tab. It first
+// tries to get past the page with solveChallenge, then with opts.Browser.PauseOnChallenge asks the user to solve it in the
+// browser window and waits up to ChallengeWait for it to go away. A page still shown fails with a *ChallengeError.
+func handleChallenge(ctx context.Context, tab BrowserTab, engine string, opts Options) error {
+	var challenge string
+	if err := tab.Evaluate(challengeJS, &challenge); err != nil {
+		return err
+	}
+	if challenge == "" {
+		return nil
+	}
+	pageURL, err := tab.Location()
+	if err != nil {
+		return err
+	}
+	challengeErr := &ChallengeError{Engine: engine, Challenge: challenge, URL: pageURL}
+
+	solved, err := solveChallenge(ctx, tab, engine, challenge, opts)
+	if err != nil {
+		slog.Warn("Failed to get past the challenge page", "engine", engine, "challenge", challenge, "error", err)
+	}
+	if !solved && opts.Browser.PauseOnChallenge {
+		slog.Info("Waiting for a challenge page to be solved", "engine", engine, "challenge", challenge)
+		fmt.Fprintf(os.Stderr, "\n%s shows a %s page. Solve it in the browser window, the search continues once it's gone.\n", engine, challenge)
+		if solved, err = waitForChallenge(ctx, tab, ChallengeWait); err != nil {
+			return err
+		}
+	}
+	if !solved {
+		return challengeErr
+	}
+	slog.Info("Challenge page solved, continuing the search", "engine", engine, "challenge", challenge)
+
+	// Give the results page the time to load, as after the first navigation
+	return sleep(ctx, 2*time.Second)
+}
+
+// loadResultsPage navigates the tab to the results page of the engine, gives it the time to load and gets past a
+// challenge page shown instead with handleChallenge
+func loadResultsPage(ctx context.Context, tab BrowserTab, engine, pageURL string, opts Options) error {
+	if err := tab.Navigate(pageURL); err != nil {
+		return err
+	}
+	if err := sleep(ctx, 2*time.Second); err != nil {
+		return err
+	}
+	return handleChallenge(ctx, tab, engine, opts)
+}
+
+// waitForChallenge polls the page of the tab until it no longer shows a challenge and reports whether that happened
+// within the timeout. It only fails if ctx is done.
+func waitForChallenge(ctx context.Context, tab BrowserTab, timeout time.Duration) (bool, error) {
+	deadline := time.After(timeout)
+	for {
+		select {
+		case <-time.After(time.Second):
+		case <-deadline:
+			return false, nil
+		case <-ctx.Done():
+			return false, ctx.Err()
+		}
+		// The page navigates once the challenge is solved, which can fail the evaluation in between
+		var challenge string
+		if err := tab.Evaluate(challengeJS, &challenge); err == nil && challenge == "" {
+			return true, nil
+		}
+	}
+}
@@ -0,0 +1,368 @@
+package searcher
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultCustomScrolls is how many times a custom engine scrolls its results page without Scroll.Count
+const defaultCustomScrolls = 5
+
+// EngineDefinition declares a browser-based engine without code, as loaded from an engines.yaml file by
+// LoadEngineFile. Exactly one of Extract, Selector and Pattern tells how to find the images on the results page.
+type EngineDefinition struct {
+	Name string `yaml:"name"` // Unique name used to select the engine
+	URL  string `yaml:"url"`  // Results page URL, with {query} replaced by the URL-encoded query
+
+	Scroll EngineScroll `yaml:"scroll"`
+
+	// Extract is a JavaScript expression returning an array of image URLs, or of objects with a url and optionally
+	// a title, page, width and height
+	Extract string `yaml:"extract"`
+	// Selector is a CSS selector of the result elements whose Attribute, "src" if empty, is the image URL
+	Selector  string `yaml:"selector"`
+	Attribute string `yaml:"attribute"`
+	// Pattern is a regular expression whose first group matches the image URLs in the page source
+	Pattern string `yaml:"pattern"`
+
+	URLParam string          `yaml:"url_param"` // Query parameter of the extracted URLs holding the image URL, for redirect links
+	Rewrites []EngineRewrite `yaml:"rewrite"`   // Replacements applied in order to every image URL
+	Include  string          `yaml:"include"`   // Regular expression image URLs have to match, empty for any
+	Exclude  string          `yaml:"exclude"`   // Regular expression of image URLs to leave out, e.g. logos and icons
+}
+
+// EngineScroll tells how a custom engine loads more results
+type EngineScroll struct {
+	Count   int    `yaml:"count"`    // Times to scroll the results page, 0 for 5
+	CountJS string `yaml:"count_js"` // Expression returning the number of results on the page, empty for the number of images
+	MoreJS  string `yaml:"more_js"`  // Expression clicking the "show more" control if it is visible, returning whether it did
+}
+
+// EngineRewrite replaces the matches of a regular expression in image URLs, e.g. to turn thumbnail URLs into
+// original ones. Replace may refer to groups of Pattern as $1.
+type EngineRewrite struct {
+	Pattern string `yaml:"pattern"`
+	Replace string `yaml:"replace"`
+}
+
+// customEngine searches with the results page of an EngineDefinition in a headless browser
+type customEngine struct {
+	def       EngineDefinition
+	scroll    scrollStrategy
+	extractJS string
+	pattern   *regexp.Regexp
+	rewrites  []compiledRewrite
+	include   *regexp.Regexp
+	exclude   *regexp.Regexp
+}
+
+// compiledRewrite is an EngineRewrite with its pattern compiled
+type compiledRewrite struct {
+	pattern *regexp.Regexp
+	replace string
+}
+
+// customItem is an image found by the extraction expression of a custom engine, either a bare URL or an object
+type customItem struct {
+	URL    string `json:"url"`
+	Title  string `json:"title"`
+	Page   string `json:"page"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+// UnmarshalJSON accepts a bare URL string as well as an object
+func (i *customItem) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		return json.Unmarshal(data, &i.URL)
+	}
+	type item customItem
+	return json.Unmarshal(data, (*item)(i))
+}
+
+// customNamePattern matches valid custom engine names, which are used in -targets and folder names
+var customNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// NewEngine checks the definition and returns the engine it declares, ready to Register
+func NewEngine(def EngineDefinition) (SearchEngine, error) {
+	if !customNamePattern.MatchString(def.Name) {
+		return nil, fmt.Errorf("invalid engine name %q, expected lowercase letters, digits, - and _", def.Name)
+	}
+	if !strings.Contains(def.URL, "{query}") {
+		return nil, fmt.Errorf("engine %s: url must contain {query}", def.Name)
+	}
+	if u, err := url.Parse(strings.ReplaceAll(def.URL, "{query}", "q")); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("engine %s: invalid url %q", def.Name, def.URL)
+	}
+	if def.Scroll.Count < 0 {
+		return nil, fmt.Errorf("engine %s: scroll count must not be negative", def.Name)
+	}
+
+	e := &customEngine{def: def, scroll: scrollStrategy{countJS: def.Scroll.CountJS, moreJS: def.Scroll.MoreJS}}
+	if e.scroll.countJS == "" {
+		e.scroll.countJS = `document.images.length`
+	}
+
+	methods := 0
+	for _, set := range []bool{def.Extract != "", def.Selector != "", def.Pattern != ""} {
+		if set {
+			methods++
+		}
+	}
+	if methods != 1 {
+		return nil, fmt.Errorf("engine %s: expected exactly one of extract, selector and pattern", def.Name)
+	}
+	var err error
+	switch {
+	case def.Extract != "":
+		e.extractJS = def.Extract
+	case def.Selector != "":
+		attribute := def.Attribute
+		if attribute == "" {
+			attribute = "src"
+		}
+		// The selector and attribute are embedded as JSON string literals, so quotes in them can't break the script
+		selector, _ := json.Marshal(def.Selector)
+		attr, _ := json.Marshal(attribute)
+		e.extractJS = fmt.Sprintf(`Array.from(document.querySelectorAll(%s)).map(e => e.getAttribute(%s)).filter(v => v).map(v => { try { return new URL(v, location.href).href; } catch (err) { return ''; } })`, selector, attr)
+	default:
+		if e.pattern, err = compileEnginePattern(def.Name, "pattern", def.Pattern); err != nil {
+			return nil, err
+		}
+		if e.pattern.NumSubexp() < 1 {
+			return nil, fmt.Errorf("engine %s: pattern needs a group matching the image URL", def.Name)
+		}
+	}
+	if def.Attribute != "" && def.Selector == "" {
+		return nil, fmt.Errorf("engine %s: attribute needs a selector", def.Name)
+	}
+
+	for _, rewrite := range def.Rewrites {
+		pattern, err := compileEnginePattern(def.Name, "rewrite pattern", rewrite.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		e.rewrites = append(e.rewrites, compiledRewrite{pattern: pattern, replace: rewrite.Replace})
+	}
+	if def.Include != "" {
+		if e.include, err = compileEnginePattern(def.Name, "include", def.Include); err != nil {
+			return nil, err
+		}
+	}
+	if def.Exclude != "" {
+		if e.exclude, err = compileEnginePattern(def.Name, "exclude", def.Exclude); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+// compileEnginePattern compiles a regular expression of an engine definition, naming the field in errors
+func compileEnginePattern(engine, field, pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("engine %s: empty %s", engine, field)
+	}
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("engine %s: invalid %s: %w", engine, field, err)
+	}
+	return compiled, nil
+}
+
+// Name returns the engine name
+func (e *customEngine) Name() string {
+	return e.def.Name
+}
+
+// UsesBrowser reports that the engine drives a browser
+func (e *customEngine) UsesBrowser() bool {
+	return true
+}
+
+// SearchURL returns the results page URL for the query
+func (e *customEngine) SearchURL(query string, opts Options) string {
+	return strings.ReplaceAll(e.def.URL, "{query}", url.QueryEscape(filteredQuery(query, opts)))
+}
+
+// Search loads the results page, scrolls it and extracts the images as the definition declares
+func (e *customEngine) Search(ctx context.Context, query string, opts Options) ([]Result, error) {
+	var items []customItem
+	var html string
+	searchURL := e.SearchURL(query, opts)
+
+	tab, err := openTab(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer tab.Close()
+
+	scrolls := e.def.Scroll.Count
+	if scrolls == 0 {
+		scrolls = defaultCustomScrolls
+	}
+	err = loadResultsPage(ctx, tab, e.Name(), searchURL, opts)
+	if err == nil {
+		err = scrollPage(ctx, tab, e.scroll, scrolls, opts)
+	}
+	if err == nil {
+		if e.pattern != nil {
+			html, err = tab.HTML()
+		} else {
+			err = tab.Evaluate(e.extractJS, &items)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s images: %w", e.Name(), err)
+	}
+
+	if captured := capturedResults(tab, e.Name(), query, searchURL); captured != nil {
+		return LimitResults(captured, opts.Limit), nil
+	}
+
+	if e.pattern != nil {
+		for _, imageURL := range parseEmbeddedURLs(html, e.pattern, nil) {
+			items = append(items, customItem{URL: imageURL})
+		}
+	}
+
+	var results []Result
+	seen := make(map[string]bool, len(items))
+	for _, item := range items {
+		imageURL, ok := e.imageURL(item.URL)
+		if !ok || seen[imageURL] {
+			continue
+		}
+		seen[imageURL] = true
+
+		pageURL := item.Page
+		if pageURL == "" {
+			pageURL = searchURL
+		}
+		results = append(results, Result{
+			URL:     imageURL,
+			PageURL: pageURL,
+			Engine:  e.Name(),
+			Query:   query,
+			Title:   item.Title,
+			Width:   item.Width,
+			Height:  item.Height,
+		})
+	}
+	return LimitResults(results, opts.Limit), nil
+}
+
+// imageURL applies the URL post-processing rules of the definition to an extracted URL, reporting whether it is kept
+func (e *customEngine) imageURL(raw string) (string, bool) {
+	imageURL := strings.TrimSpace(raw)
+	if e.def.URLParam != "" {
+		u, err := url.Parse(imageURL)
+		if err != nil {
+			return "", false
+		}
+		imageURL = u.Query().Get(e.def.URLParam)
+	}
+	for _, rewrite := range e.rewrites {
+		imageURL = rewrite.pattern.ReplaceAllString(imageURL, rewrite.replace)
+	}
+
+	if !strings.HasPrefix(imageURL, "http") {
+		return "", false
+	}
+	if e.include != nil && !e.include.MatchString(imageURL) {
+		return "", false
+	}
+	if e.exclude != nil && e.exclude.MatchString(imageURL) {
+		return "", false
+	}
+	return imageURL, true
+}
+
+// LoadEngineFile registers the engines defined in a YAML file, given either as a list of definitions or as a
+// mapping with the list under "engines", possibly split over several documents. A definition looks like:
+//
+//	engines:
+//	  - name: example
+//	    url: https://images.example.com/search?q={query}
+//	    scroll:
+//	      count: 3
+//	      more_js: document.querySelector('.more')?.click() ?? false
+//	    selector: .results img
+//	    attribute: data-src
+//	    rewrite:
+//	      - pattern: /thumb/
+//	        replace: /full/
+//	    exclude: /logo
+//
+// It fails without registering any engine if a definition is invalid or names an engine that is already registered.
+func LoadEngineFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	defs, err := parseEngineFile(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	var engines []SearchEngine
+	seen := make(map[string]bool, len(defs))
+	for _, def := range defs {
+		engine, err := NewEngine(def)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if _, exists := Lookup(def.Name); exists || seen[def.Name] {
+			return fmt.Errorf("%s: engine %s is already defined", path, def.Name)
+		}
+		seen[def.Name] = true
+		engines = append(engines, engine)
+	}
+	for _, engine := range engines {
+		Register(engine)
+	}
+	return nil
+}
+
+// parseEngineFile decodes the engine definitions of every document of an engines.yaml file. Unknown keys are
+// rejected, since they are most likely typos that would otherwise go unnoticed.
+func parseEngineFile(data []byte) ([]EngineDefinition, error) {
+	// The first decoder tells the bare lists from the mappings with an engines key, and the second decodes every
+	// document into the matching type, reporting the lines of the file in its errors
+	nodes := yaml.NewDecoder(bytes.NewReader(data))
+	strict := yaml.NewDecoder(bytes.NewReader(data))
+	strict.KnownFields(true)
+
+	var defs []EngineDefinition
+	for {
+		var doc yaml.Node
+		if err := nodes.Decode(&doc); errors.Is(err, io.EOF) {
+			return defs, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		var list []EngineDefinition
+		if len(doc.Content) > 0 && doc.Content[0].Kind == yaml.MappingNode {
+			var file struct {
+				Engines []EngineDefinition `yaml:"engines"`
+			}
+			if err := strict.Decode(&file); err != nil {
+				return nil, err
+			}
+			list = file.Engines
+		} else if err := strict.Decode(&list); err != nil {
+			return nil, err
+		}
+		defs = append(defs, list...)
+	}
+}
@@ -0,0 +1,240 @@
+package searcher
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseEngineFile(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want []EngineDefinition
+	}{
+		{
+			name: "engines key",
+			yaml: `
+engines:
+  - name: example # comment
+    url: "https://images.example.com/search?q={query}&safe=off"
+    scroll:
+      count: 3
+      more_js: document.querySelector('.more')?.click() ?? false
+    selector: '.results img[alt="a # b"]'
+    attribute: data-src
+    rewrite:
+      - pattern: /thumb/
+        replace: /full/
+    exclude: /(logo|icon)
+`,
+			want: []EngineDefinition{{
+				Name:      "example",
+				URL:       "https://images.example.com/search?q={query}&safe=off",
+				Scroll:    EngineScroll{Count: 3, MoreJS: "document.querySelector('.more')?.click() ?? false"},
+				Selector:  `.results img[alt="a # b"]`,
+				Attribute: "data-src",
+				Rewrites:  []EngineRewrite{{Pattern: "/thumb/", Replace: "/full/"}},
+				Exclude:   "/(logo|icon)",
+			}},
+		},
+		{
+			name: "bare list with block scalars",
+			yaml: `
+- name: literal
+  url: https://a.example/?q={query}
+  extract: |
+    Array.from(document.images)
+      .map(img => img.src)
+  include: >-
+    \.jpe?g$
+`,
+			want: []EngineDefinition{{
+				Name:    "literal",
+				URL:     "https://a.example/?q={query}",
+				Extract: "Array.from(document.images)\n  .map(img => img.src)\n",
+				Include: `\.jpe?g$`,
+			}},
+		},
+		{
+			name: "flow collections and anchors",
+			yaml: `
+engines:
+  - &base {name: first, url: "https://a.example/?q={query}", pattern: '"(https[^"]+)"'}
+  - <<: *base
+    name: second
+    rewrite: [{pattern: "^http:", replace: "https:"}]
+`,
+			want: []EngineDefinition{
+				{Name: "first", URL: "https://a.example/?q={query}", Pattern: `"(https[^"]+)"`},
+				{Name: "second", URL: "https://a.example/?q={query}", Pattern: `"(https[^"]+)"`, Rewrites: []EngineRewrite{{Pattern: "^http:", Replace: "https:"}}},
+			},
+		},
+		{
+			name: "several documents",
+			yaml: `
+- name: one
+  url: https://a.example/?q={query}
+  selector: img
+---
+engines:
+  - name: two
+    url: https://b.example/?q={query}
+    selector: img
+`,
+			want: []EngineDefinition{
+				{Name: "one", URL: "https://a.example/?q={query}", Selector: "img"},
+				{Name: "two", URL: "https://b.example/?q={query}", Selector: "img"},
+			},
+		},
+		{
+			name: "empty",
+			yaml: "# no engines yet\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseEngineFile([]byte(tt.yaml))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseEngineFileErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want string
+	}{
+		{"unknown key", "- name: x\n  urll: https://a.example/?q={query}\n", "line 2: field urll not found"},
+		{"unknown nested key", "- name: x\n  scroll:\n    cnt: 3\n", "line 3: field cnt not found"},
+		{"unknown top-level key", "engine:\n  - name: x\n", "line 1: field engine not found"},
+		{"wrong type", "- name: x\n  scroll:\n    count: many\n", "line 3"},
+		{"not a list", "just text\n", "line 1"},
+		{"bad indentation", "- name: x\n   url: y\n", "line 2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseEngineFile([]byte(tt.yaml))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got error %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewEngineErrors(t *testing.T) {
+	valid := EngineDefinition{Name: "valid", URL: "https://a.example/?q={query}", Selector: "img"}
+	tests := []struct {
+		name string
+		edit func(*EngineDefinition)
+		want string
+	}{
+		{"missing name", func(d *EngineDefinition) { d.Name = "" }, "invalid engine name"},
+		{"uppercase name", func(d *EngineDefinition) { d.Name = "Valid" }, "invalid engine name"},
+		{"missing query", func(d *EngineDefinition) { d.URL = "https://a.example/" }, "must contain {query}"},
+		{"not http", func(d *EngineDefinition) { d.URL = "file:///{query}" }, "invalid url"},
+		{"no extraction", func(d *EngineDefinition) { d.Selector = "" }, "exactly one of"},
+		{"two extractions", func(d *EngineDefinition) { d.Pattern = "(x)" }, "exactly one of"},
+		{"pattern without group", func(d *EngineDefinition) { d.Selector, d.Pattern = "", "https://x" }, "needs a group"},
+		{"attribute without selector", func(d *EngineDefinition) { d.Selector, d.Extract, d.Attribute = "", "[]", "src" }, "needs a selector"},
+		{"invalid rewrite", func(d *EngineDefinition) { d.Rewrites = []EngineRewrite{{Pattern: "("}} }, "invalid rewrite pattern"},
+		{"invalid exclude", func(d *EngineDefinition) { d.Exclude = "[" }, "invalid exclude"},
+		{"negative scroll", func(d *EngineDefinition) { d.Scroll.Count = -1 }, "must not be negative"},
+	}
+	if _, err := NewEngine(valid); err != nil {
+		t.Fatalf("valid definition: %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			def := valid
+			tt.edit(&def)
+			if _, err := NewEngine(def); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got error %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCustomEngineImageURL(t *testing.T) {
+	engine, err := NewEngine(EngineDefinition{
+		Name:     "rewrites",
+		URL:      "https://a.example/?q={query}",
+		Selector: "a",
+		URLParam: "imgurl",
+		Rewrites: []EngineRewrite{{Pattern: `/thumb/(\w+)`, Replace: "/full/$1"}, {Pattern: `^http:`, Replace: "https:"}},
+		Include:  `\.jpe?g$`,
+		Exclude:  `/logo`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := engine.(*customEngine)
+	tests := []struct {
+		raw  string
+		want string
+		ok   bool
+	}{
+		{"https://a.example/r?imgurl=http%3A%2F%2Fimg.example%2Fthumb%2Fcat.jpg", "https://img.example/full/cat.jpg", true},
+		{"https://a.example/r?imgurl=https%3A%2F%2Fimg.example%2Fcat.png", "", false},
+		{"https://a.example/r?imgurl=https%3A%2F%2Fimg.example%2Flogo.jpg", "", false},
+		{"https://a.example/r?other=1", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := e.imageURL(tt.raw)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("imageURL(%s) = %q, %v, want %q, %v", tt.raw, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestCustomItemUnmarshal(t *testing.T) {
+	var items []customItem
+	if err := json.Unmarshal([]byte(`["https://a.example/1.jpg", {"url": "https://a.example/2.jpg", "title": "Two", "width": 640}]`), &items); err != nil {
+		t.Fatal(err)
+	}
+	want := []customItem{{URL: "https://a.example/1.jpg"}, {URL: "https://a.example/2.jpg", Title: "Two", Width: 640}}
+	if !reflect.DeepEqual(items, want) {
+		t.Errorf("got %+v, want %+v", items, want)
+	}
+}
+
+func TestLoadEngineFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	// A clash with a built-in engine registers none of the file's engines
+	clash := write("clash.yaml", "- {name: test-load-new, url: 'https://a.example/?q={query}', selector: img}\n- {name: bing, url: 'https://a.example/?q={query}', selector: img}\n")
+	if err := LoadEngineFile(clash); err == nil || !strings.Contains(err.Error(), "bing is already defined") {
+		t.Errorf("got error %v, want bing already defined", err)
+	}
+	if _, ok := Lookup("test-load-new"); ok {
+		t.Errorf("registered an engine of a file that failed to load")
+	}
+
+	valid := write("valid.yaml", "engines:\n  - {name: test-load-new, url: 'https://a.example/?q={query}', selector: img}\n")
+	if err := LoadEngineFile(valid); err != nil {
+		t.Fatal(err)
+	}
+	engine, ok := Lookup("test-load-new")
+	if !ok || !UsesBrowser(engine) {
+		t.Fatalf("got %v, %v, want a registered browser engine", engine, ok)
+	}
+	if got := engine.(*customEngine).SearchURL("black cats", Options{}); got != "https://a.example/?q=black+cats" {
+		t.Errorf("got search URL %s", got)
+	}
+}
@@ -0,0 +1,128 @@
+package searcher
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+func init() {
+	Register(DeviantArt{})
+}
+
+// deviantArtPageSize is the largest page the DeviantArt browse endpoints return
+const deviantArtPageSize = 24
+
+// DeviantArt searches deviations through the DeviantArt OAuth API, sorted by Options.DeviantArt.Sort.
+// It needs an OAuth application, set as the "deviantart-id" and "deviantart-secret" credentials or the
+// DEVIANTART_CLIENT_ID and DEVIANTART_CLIENT_SECRET environment variables.
+type DeviantArt struct{}
+
+// Name returns the engine name
+func (DeviantArt) Name() string {
+	return "deviantart"
+}
+
+// SearchURL returns the browse URL for the query, sort ("newest" or "popular") and offset
+func (DeviantArt) SearchURL(query, sort, token string, offset int, mature bool) string {
+	params := url.Values{
+		"q":              {query},
+		"offset":         {strconv.Itoa(offset)},
+		"limit":          {strconv.Itoa(deviantArtPageSize)},
+		"mature_content": {strconv.FormatBool(mature)},
+		"access_token":   {token},
+	}
+	if sort == "popular" {
+		params.Set("timerange", "alltime")
+	}
+	return fmt.Sprintf("https://www.deviantart.com/api/v1/oauth2/browse/%s?%s", sort, params.Encode())
+}
+
+// deviantArtToken is the response of the OAuth token endpoint
+type deviantArtToken struct {
+	AccessToken string `json:"access_token"`
+}
+
+// deviantArtResponse is the response of the browse endpoints
+type deviantArtResponse struct {
+	HasMore    bool `json:"has_more"`
+	NextOffset int  `json:"next_offset"`
+	Results    []struct {
+		URL    string `json:"url"`
+		Title  string `json:"title"`
+		Author struct {
+			Username string `json:"username"`
+		} `json:"author"`
+		Content *struct {
+			Src    string `json:"src"`
+			Width  int    `json:"width"`
+			Height int    `json:"height"`
+		} `json:"content"`
+	} `json:"results"`
+}
+
+// Search authenticates with the client credentials grant and pages through the browse results.
+// Deviations without image content, such as literature, are skipped.
+func (d DeviantArt) Search(ctx context.Context, query string, opts Options) ([]Result, error) {
+	clientID := opts.credential("deviantart-id", "DEVIANTART_CLIENT_ID")
+	clientSecret := opts.credential("deviantart-secret", "DEVIANTART_CLIENT_SECRET")
+	if clientID == "" || clientSecret == "" {
+		return nil, fmt.Errorf("deviantart needs OAuth client credentials: set DEVIANTART_CLIENT_ID and DEVIANTART_CLIENT_SECRET or pass the deviantart-id and deviantart-secret credentials")
+	}
+
+	sort := opts.DeviantArt.Sort
+	switch sort {
+	case "":
+		sort = "popular"
+	case "newest", "popular":
+	default:
+		return nil, fmt.Errorf("unknown DeviantArt sort %q, expected newest or popular", sort)
+	}
+
+	var token deviantArtToken
+	err := postFormJSON(ctx, opts, "https://www.deviantart.com/oauth2/token", url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {clientID},
+		"client_secret": {clientSecret},
+	}, &token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to authenticate with DeviantArt: %v", err)
+	}
+
+	var results []Result
+	offset := 0
+	for page := 0; page < maxPages(opts); page++ {
+		if opts.Limit > 0 && len(results) >= opts.Limit {
+			break
+		}
+
+		var resp deviantArtResponse
+		if err := fetchJSON(ctx, opts, d.SearchURL(query, sort, token.AccessToken, offset, opts.Mature), nil, &resp); err != nil {
+			return nil, fmt.Errorf("failed to fetch DeviantArt deviations: %v", err)
+		}
+
+		for _, deviation := range resp.Results {
+			if deviation.Content == nil || deviation.Content.Src == "" {
+				continue
+			}
+			results = append(results, Result{
+				URL:     deviation.Content.Src,
+				PageURL: deviation.URL,
+				Engine:  d.Name(),
+				Query:   query,
+				Title:   deviation.Title,
+				Width:   deviation.Content.Width,
+				Height:  deviation.Content.Height,
+				Author:  deviation.Author.Username,
+			})
+		}
+
+		if !resp.HasMore {
+			break
+		}
+		offset = resp.NextOffset
+	}
+
+	return LimitResults(results, opts.Limit), nil
+}
@@ -0,0 +1,86 @@
+package searcher
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+func init() {
+	Register(DuckDuckGo{})
+}
+
+// vqdPattern matches the vqd token DuckDuckGo embeds in its search page
+var vqdPattern = regexp.MustCompile(`vqd=["']?([\d-]+)["']?`)
+
+// DuckDuckGo searches DuckDuckGo Images through its JSON endpoint, without a browser
+type DuckDuckGo struct{}
+
+// Name returns the engine name
+func (DuckDuckGo) Name() string {
+	return "duckduckgo"
+}
+
+// SearchURL returns the DuckDuckGo image search page URL for the query
+func (DuckDuckGo) SearchURL(query string) string {
+	return "https://duckduckgo.com/?" + url.Values{"q": {query}, "iax": {"images"}, "ia": {"images"}}.Encode()
+}
+
+// duckDuckGoResponse is the response of the i.js endpoint
+type duckDuckGoResponse struct {
+	Results []struct {
+		Image  string `json:"image"`
+		URL    string `json:"url"`
+		Title  string `json:"title"`
+		Width  int    `json:"width"`
+		Height int    `json:"height"`
+	} `json:"results"`
+	Next string `json:"next"`
+}
+
+// Search fetches the vqd token from the search page and then pages through the i.js results
+func (d DuckDuckGo) Search(ctx context.Context, query string, opts Options) ([]Result, error) {
+	searchURL := d.SearchURL(query)
+
+	page, err := fetch(ctx, opts, searchURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch DuckDuckGo search page: %v", err)
+	}
+	match := vqdPattern.FindSubmatch(page)
+	if match == nil {
+		return nil, fmt.Errorf("failed to find DuckDuckGo vqd token")
+	}
+
+	header := http.Header{"Referer": {"https://duckduckgo.com/"}}
+	next := "i.js?" + url.Values{"l": {"us-en"}, "o": {"json"}, "q": {query}, "vqd": {string(match[1])}, "f": {",,,,,"}, "p": {"1"}}.Encode()
+
+	var results []Result
+	for i := 0; i < maxPages(opts) && next != ""; i++ {
+		if opts.Limit > 0 && len(results) >= opts.Limit {
+			break
+		}
+
+		var resp duckDuckGoResponse
+		if err := fetchJSON(ctx, opts, "https://duckduckgo.com/"+strings.TrimPrefix(next, "/"), header, &resp); err != nil {
+			if len(results) > 0 {
+				break
+			}
+			return nil, fmt.Errorf("failed to fetch DuckDuckGo images: %v", err)
+		}
+
+		for _, r := range resp.Results {
+			results = append(results, Result{URL: r.Image, PageURL: r.URL, Engine: d.Name(), Query: query, Title: r.Title, Width: r.Width, Height: r.Height})
+		}
+
+		// The next page needs the vqd token, which the endpoint leaves out of the next link
+		next = resp.Next
+		if next != "" && !strings.Contains(next, "vqd=") {
+			next += "&vqd=" + url.QueryEscape(string(match[1]))
+		}
+	}
+
+	return LimitResults(results, opts.Limit), nil
+}
@@ -0,0 +1,54 @@
+package searcher
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/chromedp"
+)
+
+// emulate returns the action applying the viewport, device scale, locale and timezone of the options to a tab.
+// A mobile viewport also gets touch input and, unless stealth already set it, the user agent of a phone.
+func emulate(opts Options) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		if opts.Browser.ViewportWidth > 0 && opts.Browser.ViewportHeight > 0 || opts.Browser.Mobile {
+			width, height := int64(opts.Browser.ViewportWidth), int64(opts.Browser.ViewportHeight)
+			if width == 0 || height == 0 {
+				width, height = 412, 915
+			}
+			scale := opts.Browser.DeviceScale
+			if scale == 0 {
+				scale = 1
+			}
+			if err := emulation.SetDeviceMetricsOverride(width, height, scale, opts.Browser.Mobile).Do(ctx); err != nil {
+				return fmt.Errorf("failed to set the viewport: %v", err)
+			}
+		}
+		if opts.Browser.Mobile {
+			if err := emulation.SetTouchEmulationEnabled(true).WithMaxTouchPoints(5).Do(ctx); err != nil {
+				return fmt.Errorf("failed to enable touch input: %v", err)
+			}
+			if !opts.Browser.Stealth {
+				override, err := userAgentOverride(ctx, opts)
+				if err != nil {
+					return err
+				}
+				if err := override.Do(ctx); err != nil {
+					return err
+				}
+			}
+		}
+		if opts.Browser.Locale != "" {
+			if err := emulation.SetLocaleOverride().WithLocale(opts.Browser.Locale).Do(ctx); err != nil {
+				return fmt.Errorf("failed to set the locale: %v", err)
+			}
+		}
+		if opts.Browser.Timezone != "" {
+			if err := emulation.SetTimezoneOverride(opts.Browser.Timezone).Do(ctx); err != nil {
+				return fmt.Errorf("failed to set the timezone: %v", err)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,296 @@
+// Package searcher finds images on web search engines.
+//
+// Every engine implements SearchEngine and is registered by name, so callers can look engines up
+// from user input and third parties can plug in their own with Register.
+package searcher
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Result is a single image found by an engine
+type Result struct {
+	URL         string // Direct URL of the image
+	PageURL     string // Page the image was found on
+	Engine      string // Name of the engine that returned the image
+	Query       string // Query the image was found for
+	Title       string // Title or caption, when the engine reports it
+	Description string // Longer description, when the engine reports it
+	Width       int    // Width in pixels, when the engine reports it
+	Height      int    // Height in pixels, when the engine reports it
+	ContentType string // MIME type, when the engine reports it
+	License     string // License name, when the engine reports it
+	LicenseURL  string // Link to the license text, when the engine reports it
+	Author      string // Author or owner to credit, when the engine reports it
+
+	// Data holds the image itself when it was captured from the browser with Options.CaptureImages, so it is
+	// saved as is instead of downloaded from URL
+	Data []byte
+}
+
+// Options holds the settings of a single search. Most apply to every engine that supports them; the settings of a
+// single engine are grouped under its name, and those of the browser driven by browser-based engines under Browser.
+type Options struct {
+	Limit   int  // Maximum number of results to return, 0 for no limit
+	FullRes bool // Return original images instead of result page thumbnails where the engine distinguishes them
+
+	// Paginate keeps scrolling, clicking "show more" and paging until Limit results are found or the engine
+	// runs out, instead of scrolling a fixed number of times. MaxDepth caps the number of scrolls.
+	Paginate bool
+	MaxDepth int
+
+	// ScrollCount overrides the number of scrolls of browser-based engines without Paginate, 0 for the engine's
+	// own. ScrollDelay is the wait for images to load after every scroll, 0 for 500ms.
+	ScrollCount int
+	ScrollDelay time.Duration
+
+	Language string // Search and interface language code, e.g. "ru" or "tr"
+	Region   string // Two-letter country code of the market to search, e.g. "de" or "jp"
+
+	Orientation string // Restricts results to "landscape", "portrait" or "square" on engines that support it
+
+	Size  ImageSize // Restricts results by size on Google, Bing and Yandex
+	Color string    // Restricts results to a color filter accepted by ParseColor on Google, Bing and Yandex
+	Type  string    // Restricts results to "photo", "clipart", "lineart" or "animated" images on Google, Bing and Yandex
+
+	// Content restricts Google, Bing and Yandex results to images of people ("faces"), taking the place of Type on
+	// Google and Yandex, or approximates images without people ("none") by excluding words like "people" from the query
+	Content string
+
+	Aspect string // Restricts results to "wide", "tall", "square" or "panoramic" images on Google, Bing and Yandex
+
+	// Formats restricts Google and Yandex results to a file format accepted by ParseFormats. The engines only
+	// filter by a single format, so a longer list is only enforced after the search.
+	Formats []string
+
+	// License restricts Google and Bing results to images licensed under Creative Commons ("creativecommons")
+	// or for commercial use ("commercial")
+	License string
+
+	Since Since // Restricts Google and Bing results to images published within a period or after a date
+
+	SafeSearch string // SafeSearch level on Google, Bing and Yandex: "off", "moderate" or "strict", empty for the engine default
+
+	Mature bool // Includes mature content on engines that hide it by default
+
+	AnimationFormat string // Rendition returned by animated GIF engines: "gif" (default) or "mp4"
+
+	MinWidth  int // Minimum image width in pixels on engines that filter at the source, 0 for any
+	MinHeight int // Minimum image height in pixels on engines that filter at the source, 0 for any
+
+	Yandex     YandexOptions
+	Reddit     RedditOptions
+	Imgur      ImgurOptions
+	DeviantArt DeviantArtOptions
+	Flickr     FlickrOptions
+	Europeana  EuropeanaOptions
+	Pexels     PexelsOptions
+	Pixabay    PixabayOptions
+
+	Proxy string // HTTP or HTTPS proxy URL for the browser and API requests, e.g. "http://proxy.example.com:3128"
+
+	Browser BrowserOptions
+
+	// NoBrowser searches without Chrome: engines implementing FallbackEngine fetch the plain HTML of their results
+	// page, and other browser-based engines fail with ErrNoBrowser
+	NoBrowser bool
+
+	// Credentials holds API keys and similar secrets for API-based engines, keyed by credential name (e.g. "bing-api").
+	// Engines fall back to an environment variable when a credential is not set here.
+	Credentials map[string]string
+}
+
+// YandexOptions are the settings of the yandex engine
+type YandexOptions struct {
+	Region string // Yandex region ID (lr), e.g. "213" for Moscow or "11508" for Istanbul
+}
+
+// RedditOptions are the settings of the reddit engine
+type RedditOptions struct {
+	Subreddit string // Restricts results to a single subreddit, without the r/ prefix
+}
+
+// ImgurOptions are the settings of the imgur engine
+type ImgurOptions struct {
+	Tag bool // Treats the query as an Imgur tag instead of a search query
+}
+
+// DeviantArtOptions are the settings of the deviantart engine
+type DeviantArtOptions struct {
+	Sort string // Result order: "popular" (default) or "newest"
+}
+
+// FlickrOptions are the settings of the flickr engine
+type FlickrOptions struct {
+	License string // Comma-separated Flickr license IDs to allow, as returned by ParseFlickrLicense; empty for any
+}
+
+// EuropeanaOptions are the settings of the europeana engine
+type EuropeanaOptions struct {
+	// Rights restricts results to a comma-separated list of reusability categories: "open", "restricted" or "permission"
+	Rights string
+}
+
+// PexelsOptions are the settings of the pexels engine
+type PexelsOptions struct {
+	Size string // Rendition to download: "original" (default), "large" or "medium"
+}
+
+// PixabayOptions are the settings of the pixabay engine
+type PixabayOptions struct {
+	Category  string // Pixabay category, e.g. "nature" or "animals"
+	ImageType string // Pixabay image type: "all", "photo", "illustration" or "vector"
+}
+
+// BrowserOptions are the settings of the Chrome driven by browser-based engines
+type BrowserOptions struct {
+	UserDataDir string // Chrome profile directory, to reuse a logged-in session
+	CookieFile  string // JSON cookie export or Netscape cookies.txt file loaded into the browser before searching
+
+	ChromePath string // Chrome or Chromium executable to start, empty to look it up on the PATH
+
+	// ChromeFlags are command line switches added to the started Chrome, keyed by name without the leading dashes.
+	// A true value adds the switch alone, e.g. "no-sandbox", false removes a default one, and any other value is
+	// passed as --name=value. They override the defaults, so "headless": false shows the browser.
+	ChromeFlags map[string]any
+
+	// Stealth hides the signs of an automated headless Chrome that make engines answer with empty or CAPTCHA pages:
+	// the navigator.webdriver flag, "Headless" in the user agent and client hints, the missing plugins and the
+	// software WebGL renderer, among others
+	Stealth bool
+
+	Headful bool // Shows the started Chrome's window instead of running it headless
+
+	// PauseOnChallenge waits up to ChallengeWait for the user to solve a CAPTCHA or consent page shown instead of
+	// results that CaptchaSolver couldn't, which needs a visible browser from Headful or RemoteBrowser
+	PauseOnChallenge bool
+
+	// ViewportWidth and ViewportHeight set the size of the page in CSS pixels, 0 to keep the window's size.
+	// DeviceScale is the device pixel ratio, 0 for 1. Mobile emulates a phone with touch input and a mobile user
+	// agent, in a 412x915 viewport unless one is set, since engines serve phones a different layout.
+	ViewportWidth  int
+	ViewportHeight int
+	DeviceScale    float64
+	Mobile         bool
+
+	Locale   string // ICU locale of the page's Intl APIs, e.g. "de-DE", empty to keep the browser's
+	Timezone string // IANA timezone of the page, e.g. "Europe/Berlin", empty to keep the browser's
+
+	// CaptureImages returns the images the results page loaded, such as thumbnails and previews, with their contents
+	// in Result.Data, instead of the image URLs the engine extracted. Saving them from the browser gets past hotlink
+	// protection and original images that are gone, at the resolution of the page.
+	CaptureImages bool
+
+	// CaptchaSolver is the 2captcha-compatible service solving reCAPTCHA and hCaptcha challenges, either "2captcha",
+	// "rucaptcha" or the base URL of another service with the same API; empty to not solve them. Its API key is the
+	// "captcha" credential.
+	CaptchaSolver string
+
+	// Backend is the name of the BrowserBackend driving the browser, empty for DefaultBackend. Stealth, emulation
+	// and image capture are up to the backend; chromedp supports them all.
+	Backend string
+
+	// RemoteBrowser is the DevTools endpoint of a running Chrome to search in instead of starting one, either its
+	// WebSocket URL (ws://host:9222/devtools/browser/...) or its HTTP address (http://host:9222). UserDataDir,
+	// ChromePath, ChromeFlags and Options.Proxy don't apply to a remote browser, which keeps the settings it was
+	// started with.
+	RemoteBrowser string
+}
+
+// credential returns the named credential from the options or, if unset, from the environment variable
+func (o Options) credential(name, env string) string {
+	if value := o.Credentials[name]; value != "" {
+		return value
+	}
+	return os.Getenv(env)
+}
+
+// SearchEngine is implemented by every image source
+type SearchEngine interface {
+	// Name returns the unique name used to select the engine, e.g. "google"
+	Name() string
+	// Search returns the images found for the query
+	Search(ctx context.Context, query string, opts Options) ([]Result, error)
+}
+
+// BrowserEngine is implemented by engines that drive a browser, so callers can limit how many run at once
+type BrowserEngine interface {
+	SearchEngine
+	UsesBrowser() bool
+}
+
+// FallbackEngine is implemented by browser-based engines that can also search without a browser, fetching the plain
+// HTML of their results page. They do so when Options.NoBrowser is set, finding fewer results.
+type FallbackEngine interface {
+	BrowserEngine
+	SearchWithoutBrowser(ctx context.Context, query string, opts Options) ([]Result, error)
+}
+
+// HasFallback reports whether the engine can search without a browser
+func HasFallback(engine SearchEngine) bool {
+	_, ok := engine.(FallbackEngine)
+	return ok
+}
+
+// UsesBrowser reports whether the engine drives a browser
+func UsesBrowser(engine SearchEngine) bool {
+	browserEngine, ok := engine.(BrowserEngine)
+	return ok && browserEngine.UsesBrowser()
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]SearchEngine)
+	names      []string
+)
+
+// Register makes an engine available by its name. It panics if an engine with the same name is already registered.
+func Register(engine SearchEngine) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	name := engine.Name()
+	if _, exists := registry[name]; exists {
+		panic(fmt.Sprintf("searcher: engine %q registered twice", name))
+	}
+	registry[name] = engine
+	names = append(names, name)
+}
+
+// Lookup returns the engine registered with the given name
+func Lookup(name string) (SearchEngine, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	engine, ok := registry[name]
+	return engine, ok
+}
+
+// Names returns the names of all registered engines in registration order
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	return append([]string(nil), names...)
+}
+
+// LimitResults truncates the results to the limit, if one is set
+func LimitResults(results []Result, limit int) []Result {
+	if limit > 0 && len(results) > limit {
+		return results[:limit]
+	}
+	return results
+}
+
+// NewResults wraps plain image URLs into results attributed to the engine and query
+func newResults(imageURLs []string, engine, query, pageURL string) []Result {
+	results := make([]Result, 0, len(imageURLs))
+	for _, imageURL := range imageURLs {
+		results = append(results, Result{URL: imageURL, PageURL: pageURL, Engine: engine, Query: query})
+	}
+	return results
+}
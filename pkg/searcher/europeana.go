@@ -0,0 +1,157 @@
+package searcher
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	Register(Europeana{})
+}
+
+// europeanaPageSize is the largest page the Europeana Search API returns
+const europeanaPageSize = 100
+
+// europeanaReusability are the rights categories accepted in Options.Europeana.Rights
+var europeanaReusability = map[string]bool{"open": true, "restricted": true, "permission": true}
+
+// europeanaCCPattern matches Creative Commons license and public domain URLs, capturing the type and version
+var europeanaCCPattern = regexp.MustCompile(`creativecommons\.org/(?:licenses|publicdomain)/([a-z-]+)/([0-9.]+)`)
+
+// Europeana searches European cultural heritage images through the Europeana Search API.
+// It needs an API key, set as the "europeana" credential or the EUROPEANA_API_KEY environment variable.
+type Europeana struct{}
+
+// Name returns the engine name
+func (Europeana) Name() string {
+	return "europeana"
+}
+
+// SearchURL returns the search URL for the query, starting at the 1-based result index.
+// rights is a comma-separated list of reusability categories, empty for any rights statement.
+func (Europeana) SearchURL(query, key, rights string, start int) string {
+	params := url.Values{
+		"wskey":   {key},
+		"query":   {query},
+		"qf":      {"TYPE:IMAGE"},
+		"media":   {"true"},
+		"profile": {"minimal"},
+		"rows":    {strconv.Itoa(europeanaPageSize)},
+		"start":   {strconv.Itoa(start)},
+	}
+	for _, category := range strings.Split(rights, ",") {
+		if category = strings.TrimSpace(category); category != "" {
+			params.Add("reusability", category)
+		}
+	}
+	return "https://api.europeana.eu/record/v2/search.json?" + params.Encode()
+}
+
+// europeanaResponse is the response of the search endpoint
+type europeanaResponse struct {
+	Success      bool   `json:"success"`
+	Error        string `json:"error"`
+	TotalResults int    `json:"totalResults"`
+	Items        []struct {
+		GUID          string   `json:"guid"`
+		Title         []string `json:"title"`
+		DCCreator     []string `json:"dcCreator"`
+		Rights        []string `json:"rights"`
+		EDMIsShownBy  []string `json:"edmIsShownBy"`
+		EDMPreview    []string `json:"edmPreview"`
+		DCDescription []string `json:"dcDescription"`
+		DataProvider  []string `json:"dataProvider"`
+	} `json:"items"`
+}
+
+// Search pages through the Europeana image search results.
+// With Options.FullRes the provider's original file is returned, otherwise Europeana's preview.
+func (e Europeana) Search(ctx context.Context, query string, opts Options) ([]Result, error) {
+	key := opts.credential("europeana", "EUROPEANA_API_KEY")
+	if key == "" {
+		return nil, fmt.Errorf("europeana needs an API key: set EUROPEANA_API_KEY or pass the europeana credential")
+	}
+	for _, category := range strings.Split(opts.Europeana.Rights, ",") {
+		if category = strings.TrimSpace(category); category != "" && !europeanaReusability[category] {
+			return nil, fmt.Errorf("invalid Europeana rights category %q, expected open, restricted or permission", category)
+		}
+	}
+
+	var results []Result
+	for page := 0; page < maxPages(opts); page++ {
+		if opts.Limit > 0 && len(results) >= opts.Limit {
+			break
+		}
+
+		var resp europeanaResponse
+		start := page*europeanaPageSize + 1
+		if err := fetchJSON(ctx, opts, e.SearchURL(query, key, opts.Europeana.Rights, start), nil, &resp); err != nil {
+			return nil, fmt.Errorf("failed to fetch Europeana images: %v", err)
+		}
+		if !resp.Success {
+			return nil, fmt.Errorf("europeana error: %s", resp.Error)
+		}
+
+		for _, item := range resp.Items {
+			imageURL := first(item.EDMPreview)
+			if opts.FullRes && first(item.EDMIsShownBy) != "" {
+				imageURL = first(item.EDMIsShownBy)
+			}
+			if imageURL == "" {
+				continue
+			}
+
+			author := first(item.DCCreator)
+			if author == "" {
+				author = first(item.DataProvider)
+			}
+			rights := first(item.Rights)
+			results = append(results, Result{
+				URL:         imageURL,
+				PageURL:     item.GUID,
+				Engine:      e.Name(),
+				Query:       query,
+				Title:       first(item.Title),
+				Description: first(item.DCDescription),
+				License:     europeanaLicense(rights),
+				LicenseURL:  rights,
+				Author:      author,
+			})
+		}
+
+		if start+len(resp.Items) > resp.TotalResults || len(resp.Items) == 0 {
+			break
+		}
+	}
+
+	return LimitResults(results, opts.Limit), nil
+}
+
+// europeanaLicense turns a rights statement URL into a readable name, e.g. "CC BY-SA 4.0".
+// Statements that are not Creative Commons licenses, such as those of rightsstatements.org, are returned as is.
+func europeanaLicense(rights string) string {
+	match := europeanaCCPattern.FindStringSubmatch(rights)
+	if match == nil {
+		return rights
+	}
+	switch match[1] {
+	case "zero":
+		return "CC0 " + match[2]
+	case "mark":
+		return "Public Domain Mark " + match[2]
+	default:
+		return "CC " + strings.ToUpper(match[1]) + " " + match[2]
+	}
+}
+
+// first returns the first value of a multi-valued field, or an empty string
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
@@ -0,0 +1,552 @@
+package searcher
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ImageSize restricts results by size on engines that filter at the source. The zero value allows any size.
+type ImageSize struct {
+	Class      string // "large", "medium" or "icon"
+	Megapixels int    // Minimum size in megapixels, from ">2MP"
+	Width      int    // Minimum width in pixels, from "WxH"
+	Height     int    // Minimum height in pixels, from "WxH"
+}
+
+var (
+	megapixelsPattern = regexp.MustCompile(`(?i)^>\s*(\d+)\s*mp$`)
+	dimensionsPattern = regexp.MustCompile(`(?i)^(\d+)\s*x\s*(\d+)$`)
+)
+
+// ParseImageSize parses a size filter: "large", "medium", "icon", a minimum in megapixels like ">2MP",
+// or minimum dimensions like "1920x1080". An empty string allows any size.
+func ParseImageSize(s string) (ImageSize, error) {
+	s = strings.TrimSpace(s)
+	switch strings.ToLower(s) {
+	case "":
+		return ImageSize{}, nil
+	case "large", "medium", "icon":
+		return ImageSize{Class: strings.ToLower(s)}, nil
+	}
+	if match := megapixelsPattern.FindStringSubmatch(s); match != nil {
+		megapixels, _ := strconv.Atoi(match[1])
+		if megapixels > 0 {
+			return ImageSize{Megapixels: megapixels}, nil
+		}
+	}
+	if match := dimensionsPattern.FindStringSubmatch(s); match != nil {
+		width, _ := strconv.Atoi(match[1])
+		height, _ := strconv.Atoi(match[2])
+		if width > 0 && height > 0 {
+			return ImageSize{Width: width, Height: height}, nil
+		}
+	}
+	return ImageSize{}, fmt.Errorf("invalid size %q: use large, medium, icon, a minimum like >2MP or dimensions like 1920x1080", s)
+}
+
+// imageColors lists the color filters: full color, black and white, transparent background, or a dominant color
+var imageColors = []string{"color", "bw", "transparent", "red", "orange", "yellow", "green", "teal", "blue", "purple", "pink", "white", "gray", "black", "brown"}
+
+// ParseColor checks a color filter and returns it in lower case. An empty string allows any color.
+func ParseColor(s string) (string, error) {
+	color := strings.ToLower(strings.TrimSpace(s))
+	if color != "" && !slices.Contains(imageColors, color) {
+		return "", fmt.Errorf("invalid color %q: use one of %s", s, strings.Join(imageColors, ", "))
+	}
+	return color, nil
+}
+
+// imageTypes lists the image type filters
+var imageTypes = []string{"photo", "clipart", "lineart", "animated"}
+
+// ParseImageType checks an image type filter and returns it in lower case. An empty string allows any type.
+func ParseImageType(s string) (string, error) {
+	imageType := strings.ToLower(strings.TrimSpace(s))
+	if imageType != "" && !slices.Contains(imageTypes, imageType) {
+		return "", fmt.Errorf("invalid image type %q: use one of %s", s, strings.Join(imageTypes, ", "))
+	}
+	return imageType, nil
+}
+
+// googleLicenses maps usage rights filters to Google's sur values
+var googleLicenses = map[string]string{
+	"creativecommons": "cl",
+	"commercial":      "ol",
+}
+
+// bingLicenses maps usage rights filters to Bing's license filters
+var bingLicenses = map[string]string{
+	"creativecommons": "licenseType-Any",
+	"commercial":      "license-L2_L3_L4",
+}
+
+// ParseLicense checks a usage rights filter, "creativecommons" or "commercial", and returns it in lower case.
+// An empty string allows any license.
+func ParseLicense(s string) (string, error) {
+	license := strings.ToLower(strings.TrimSpace(s))
+	if _, ok := googleLicenses[license]; license != "" && !ok {
+		return "", fmt.Errorf("invalid license %q: use creativecommons or commercial", s)
+	}
+	return license, nil
+}
+
+// ParseSafeSearch checks a SafeSearch level, "off", "moderate" or "strict", and returns it in lower case.
+// An empty string leaves every engine at its default.
+func ParseSafeSearch(s string) (string, error) {
+	level := strings.ToLower(strings.TrimSpace(s))
+	switch level {
+	case "", "off", "moderate", "strict":
+		return level, nil
+	}
+	return "", fmt.Errorf("invalid safesearch level %q: use off, moderate or strict", s)
+}
+
+// googleSafeSearch maps SafeSearch levels to Google's safe values. Google has no parameter for its moderate
+// level, which blurs explicit images and is what it uses when safe is left out.
+var googleSafeSearch = map[string]string{
+	"off":    "off",
+	"strict": "active",
+}
+
+// yandexSafeSearch maps SafeSearch levels to Yandex's family values. Moderate filtering is the Yandex default.
+var yandexSafeSearch = map[string]string{
+	"off":    "no",
+	"strict": "yes",
+}
+
+// Since restricts results to images published recently, either within a period like the last 7 days or after a date.
+// The zero value allows any date.
+type Since struct {
+	Count int       // Number of units in the period, 0 when Date is set
+	Unit  string    // Unit of the period: "h" (hours), "d" (days), "w" (weeks), "m" (months) or "y" (years)
+	Date  time.Time // Earliest publishing date
+}
+
+// sinceUnits holds the length of every period unit, with months and years rounded to whole days
+var sinceUnits = map[string]time.Duration{
+	"h": time.Hour,
+	"d": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour,
+	"m": 30 * 24 * time.Hour,
+	"y": 365 * 24 * time.Hour,
+}
+
+var sincePattern = regexp.MustCompile(`(?i)^(\d+)\s*([hdwmy])$`)
+
+// ParseSince parses a date filter: a period like "24h", "7d", "2w", "6m" or "1y", or a date like "2023-01-01".
+// An empty string allows any date.
+func ParseSince(s string) (Since, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Since{}, nil
+	}
+	if match := sincePattern.FindStringSubmatch(s); match != nil {
+		count, _ := strconv.Atoi(match[1])
+		if count > 0 {
+			return Since{Count: count, Unit: strings.ToLower(match[2])}, nil
+		}
+	}
+	if date, err := time.ParseInLocation(time.DateOnly, s, time.Local); err == nil {
+		return Since{Date: date}, nil
+	}
+	return Since{}, fmt.Errorf("invalid date filter %q: use a period like 24h, 7d, 2w, 6m or 1y, or a date like 2023-01-01", s)
+}
+
+// age returns how far back the filter reaches from now, 0 if it is not set
+func (s Since) age(now time.Time) time.Duration {
+	if !s.Date.IsZero() {
+		return now.Sub(s.Date)
+	}
+	return time.Duration(s.Count) * sinceUnits[s.Unit]
+}
+
+// aspectTolerance is how far from 1:1 the ratio of a square image may be, and how far past it a wide or tall one
+// has to be, since engines classify images with some slack
+const aspectTolerance = 1.1
+
+// panoramicRatio is the smallest width to height ratio of a panoramic image
+const panoramicRatio = 2.0
+
+// ParseAspect checks an aspect ratio filter, "wide", "tall", "square" or "panoramic", and returns it in lower case.
+// An empty string allows any aspect ratio.
+func ParseAspect(s string) (string, error) {
+	aspect := strings.ToLower(strings.TrimSpace(s))
+	switch aspect {
+	case "", "wide", "tall", "square", "panoramic":
+		return aspect, nil
+	}
+	return "", fmt.Errorf("invalid aspect ratio %q: use wide, tall, square or panoramic", s)
+}
+
+// MatchesAspect reports whether an image of the given dimensions has the aspect ratio, which is always the case
+// for an empty aspect ratio or unknown dimensions
+func MatchesAspect(aspect string, width, height int) bool {
+	if aspect == "" || width <= 0 || height <= 0 {
+		return true
+	}
+	ratio := float64(width) / float64(height)
+	switch aspect {
+	case "wide":
+		return ratio >= aspectTolerance
+	case "tall":
+		return ratio <= 1/aspectTolerance
+	case "square":
+		return ratio > 1/aspectTolerance && ratio < aspectTolerance
+	case "panoramic":
+		return ratio >= panoramicRatio
+	}
+	return true
+}
+
+// googleAspects maps aspect ratio filters to Google's iar values
+var googleAspects = map[string]string{
+	"wide":      "w",
+	"tall":      "t",
+	"square":    "s",
+	"panoramic": "xw",
+}
+
+// bingAspects maps aspect ratio filters to Bing's aspect filters. Bing has no panoramic filter, so wide is used.
+var bingAspects = map[string]string{
+	"wide":      "wide",
+	"tall":      "tall",
+	"square":    "square",
+	"panoramic": "wide",
+}
+
+// yandexAspects maps aspect ratio filters to Yandex's iorient values. Yandex has no panoramic filter either.
+var yandexAspects = map[string]string{
+	"wide":      "horizontal",
+	"tall":      "vertical",
+	"square":    "square",
+	"panoramic": "horizontal",
+}
+
+// imageFormats maps the accepted file format names to their canonical name
+var imageFormats = map[string]string{
+	"jpg":  "jpg",
+	"jpeg": "jpg",
+	"png":  "png",
+	"gif":  "gif",
+	"webp": "webp",
+	"svg":  "svg",
+	"bmp":  "bmp",
+	"ico":  "ico",
+	"avif": "avif",
+	"heic": "heic",
+	"tif":  "tiff",
+	"tiff": "tiff",
+}
+
+// ParseFormats parses a comma-separated list of file formats like "jpg,png,webp" into their canonical names,
+// e.g. "jpeg" becomes "jpg". An empty list allows every format.
+func ParseFormats(list string) ([]string, error) {
+	var formats []string
+	for _, name := range strings.Split(list, ",") {
+		name = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(name), "."))
+		if name == "" {
+			continue
+		}
+		format, ok := imageFormats[name]
+		if !ok {
+			return nil, fmt.Errorf("invalid format %q: use jpg, png, gif, webp, svg, bmp, ico, avif, heic or tiff", name)
+		}
+		if !slices.Contains(formats, format) {
+			formats = append(formats, format)
+		}
+	}
+	return formats, nil
+}
+
+// googleFormats lists the formats Google filters by with ift
+var googleFormats = []string{"jpg", "png", "gif", "webp", "svg", "bmp", "ico"}
+
+// yandexFormats maps formats to Yandex's itype values. Yandex only filters GIFs by whether they are animated.
+var yandexFormats = map[string]string{
+	"jpg": "jpg",
+	"png": "png",
+	"gif": "gifan",
+}
+
+// singleFormat returns the only format in opts.Formats, since engines filter by a single format at a time
+func singleFormat(opts Options) string {
+	if len(opts.Formats) != 1 {
+		return ""
+	}
+	return opts.Formats[0]
+}
+
+// yandexCountryRegions maps country codes to the Yandex region ID of the country, used when no region ID is given
+var yandexCountryRegions = map[string]string{
+	"ru": "225",
+	"ua": "187",
+	"by": "149",
+	"kz": "159",
+	"tr": "983",
+	"us": "84",
+	"de": "96",
+	"gb": "102",
+}
+
+// bingMarket returns the Bing market code like "de-DE" for the language and region in opts, empty unless both are set
+func bingMarket(opts Options) string {
+	if opts.Language == "" || opts.Region == "" {
+		return ""
+	}
+	return strings.ToLower(opts.Language) + "-" + strings.ToUpper(opts.Region)
+}
+
+// AcceptLanguage returns the Accept-Language header for the language and region in opts, preferring the regional
+// variant and falling back to English, or an empty string if no language is set
+func AcceptLanguage(opts Options) string {
+	if opts.Language == "" {
+		return ""
+	}
+	lang := strings.ToLower(opts.Language)
+	header := lang
+	if opts.Region != "" {
+		header = lang + "-" + strings.ToUpper(opts.Region) + "," + lang + ";q=0.9"
+	}
+	if lang != "en" {
+		header += ",en;q=0.5"
+	}
+	return header
+}
+
+// ParseContent checks a content filter, "faces" for images of people or "none" for images without them,
+// and returns it in lower case. An empty string allows any content.
+func ParseContent(s string) (string, error) {
+	content := strings.ToLower(strings.TrimSpace(s))
+	switch content {
+	case "", "faces", "none":
+		return content, nil
+	}
+	return "", fmt.Errorf("invalid content filter %q: use faces or none", s)
+}
+
+// peopleExclusions are added to the query for the "none" content filter, since no engine has a facet for
+// images without people
+const peopleExclusions = "-people -person -portrait -selfie -face"
+
+// filteredQuery returns the query with the exclusions of the filters in opts that engines only support as
+// query operators
+func filteredQuery(query string, opts Options) string {
+	if opts.Content == "none" {
+		return query + " " + peopleExclusions
+	}
+	return query
+}
+
+// sizeThreshold is one of the "larger than" sizes Google offers, which Bing and Yandex minimums are derived from too
+type sizeThreshold struct {
+	name          string
+	width, height int
+}
+
+// sizeThresholds lists Google's "larger than" sizes from smallest to largest
+var sizeThresholds = []sizeThreshold{
+	{"qsvga", 400, 300},
+	{"vga", 640, 480},
+	{"svga", 800, 600},
+	{"xga", 1024, 768},
+	{"2mp", 1600, 1200},
+	{"4mp", 2272, 1704},
+	{"6mp", 2816, 2112},
+	{"8mp", 3264, 2448},
+	{"10mp", 3648, 2736},
+	{"12mp", 4096, 3072},
+	{"15mp", 4480, 3360},
+	{"20mp", 5120, 3840},
+	{"40mp", 7216, 5412},
+	{"70mp", 9600, 7200},
+}
+
+// minimum returns the largest threshold that every image passing the size filter also passes,
+// so filtering on it at the source never drops an image the filter would keep
+func (s ImageSize) minimum() (sizeThreshold, bool) {
+	var found sizeThreshold
+	ok := false
+	for _, t := range sizeThresholds {
+		fits := false
+		switch {
+		case s.Megapixels > 0:
+			fits = t.width*t.height <= s.Megapixels*1000000
+		case s.Width > 0:
+			fits = t.width <= s.Width && t.height <= s.Height
+		}
+		if !fits {
+			break
+		}
+		found, ok = t, true
+	}
+	return found, ok
+}
+
+// googleSizes maps ImageSize classes to Google's isz values
+var googleSizes = map[string]string{
+	"large":  "l",
+	"medium": "m",
+	"icon":   "i",
+}
+
+// googleTBS returns the tbs parameter of a Google image search for the filters in opts, empty if there are none
+func googleTBS(opts Options) string {
+	var tbs []string
+	if size := googleSizes[opts.Size.Class]; size != "" {
+		tbs = append(tbs, "isz:"+size)
+	} else if min, ok := opts.Size.minimum(); ok {
+		tbs = append(tbs, "isz:lt", "islt:"+min.name)
+	}
+	switch opts.Color {
+	case "":
+	case "color":
+		tbs = append(tbs, "ic:color")
+	case "bw":
+		tbs = append(tbs, "ic:gray")
+	case "transparent":
+		tbs = append(tbs, "ic:trans")
+	default:
+		tbs = append(tbs, "ic:specific", "isc:"+opts.Color)
+	}
+	// Faces are an image type on Google, so they take the place of -type
+	if opts.Content == "faces" {
+		tbs = append(tbs, "itp:face")
+	} else if opts.Type != "" {
+		tbs = append(tbs, "itp:"+opts.Type)
+	}
+	if license := googleLicenses[opts.License]; license != "" {
+		tbs = append(tbs, "sur:"+license)
+	}
+	if aspect := googleAspects[opts.Aspect]; aspect != "" {
+		tbs = append(tbs, "iar:"+aspect)
+	}
+	if format := singleFormat(opts); slices.Contains(googleFormats, format) {
+		tbs = append(tbs, "ift:"+format)
+	}
+	if !opts.Since.Date.IsZero() {
+		tbs = append(tbs, "cdr:1", "cd_min:"+opts.Since.Date.Format("1/2/2006"), "cd_max:")
+	} else if opts.Since.Count > 0 {
+		tbs = append(tbs, fmt.Sprintf("qdr:%s%d", opts.Since.Unit, opts.Since.Count))
+	}
+	return strings.Join(tbs, ",")
+}
+
+// bingSizes maps ImageSize classes to Bing's imagesize filters
+var bingSizes = map[string]string{
+	"large":  "large",
+	"medium": "medium",
+	"icon":   "small",
+}
+
+// bingTypes maps image type filters to Bing's photo filters
+var bingTypes = map[string]string{
+	"photo":    "photo",
+	"clipart":  "clipart",
+	"lineart":  "linedrawing",
+	"animated": "animatedgif",
+}
+
+// bingQFT returns the qft parameter of a Bing image search for the filters in opts, empty if there are none
+func bingQFT(opts Options) string {
+	var filters []string
+	if size := bingSizes[opts.Size.Class]; size != "" {
+		filters = append(filters, "imagesize-"+size)
+	} else if opts.Size.Width > 0 {
+		filters = append(filters, fmt.Sprintf("imagesize-custom_%d_%d", opts.Size.Width, opts.Size.Height))
+	} else if min, ok := opts.Size.minimum(); ok {
+		filters = append(filters, fmt.Sprintf("imagesize-custom_%d_%d", min.width, min.height))
+	}
+	switch opts.Color {
+	case "":
+	case "color":
+		filters = append(filters, "color2-color")
+	case "bw":
+		filters = append(filters, "color2-bw")
+	case "transparent":
+		filters = append(filters, "photo-transparent")
+	default:
+		filters = append(filters, "color2-FGcls_"+strings.ToUpper(opts.Color))
+	}
+	if imageType := bingTypes[opts.Type]; imageType != "" {
+		filters = append(filters, "photo-"+imageType)
+	}
+	if opts.Content == "faces" {
+		filters = append(filters, "face-face")
+	}
+	if license := bingLicenses[opts.License]; license != "" {
+		filters = append(filters, license)
+	}
+	if aspect := bingAspects[opts.Aspect]; aspect != "" {
+		filters = append(filters, "aspect-"+aspect)
+	}
+	// Bing takes the age in minutes
+	if age := opts.Since.age(time.Now()); age > 0 {
+		filters = append(filters, fmt.Sprintf("age-lt%d", int(age.Minutes())))
+	}
+
+	var qft strings.Builder
+	for _, filter := range filters {
+		qft.WriteString("+filterui:" + filter)
+	}
+	return qft.String()
+}
+
+// yandexSizes maps ImageSize classes to Yandex's isize values
+var yandexSizes = map[string]string{
+	"large":  "large",
+	"medium": "medium",
+	"icon":   "small",
+}
+
+// yandexColors maps color filters to Yandex's icolor values. Yandex has no filter for transparency or
+// for pink, brown and gray images, so those are left out.
+var yandexColors = map[string]string{
+	"color":  "color",
+	"bw":     "gray",
+	"red":    "red",
+	"orange": "orange",
+	"yellow": "yellow",
+	"green":  "green",
+	"teal":   "cyan",
+	"blue":   "blue",
+	"purple": "violet",
+	"white":  "white",
+	"black":  "black",
+}
+
+// yandexFilters adds the parameters of a Yandex image search for the filters in opts.
+// Yandex only filters by exact dimensions, so minimum sizes of at least XGA are approximated with "large".
+func yandexFilters(params url.Values, opts Options) {
+	if size := yandexSizes[opts.Size.Class]; size != "" {
+		params.Set("isize", size)
+	} else if min, ok := opts.Size.minimum(); ok && min.width >= 1024 {
+		params.Set("isize", "large")
+	}
+	if color := yandexColors[opts.Color]; color != "" {
+		params.Set("icolor", color)
+	}
+	if orientation := yandexAspects[opts.Aspect]; orientation != "" {
+		params.Set("iorient", orientation)
+	}
+	if format := yandexFormats[singleFormat(opts)]; format != "" && opts.Type != "animated" {
+		params.Set("itype", format)
+	}
+	if family := yandexSafeSearch[opts.SafeSearch]; family != "" {
+		params.Set("family", family)
+	}
+	// Yandex filters animated images by file type rather than by image type
+	switch {
+	case opts.Content == "faces":
+		params.Set("type", "face")
+	case opts.Type == "":
+	case opts.Type == "animated":
+		params.Set("itype", "gifan")
+	default:
+		params.Set("type", opts.Type)
+	}
+}
@@ -0,0 +1,269 @@
+package searcher
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseImageSize(t *testing.T) {
+	tests := []struct {
+		value string
+		want  ImageSize
+		err   bool
+	}{
+		{"", ImageSize{}, false},
+		{"Large", ImageSize{Class: "large"}, false},
+		{"icon", ImageSize{Class: "icon"}, false},
+		{">2MP", ImageSize{Megapixels: 2}, false},
+		{"> 12 mp", ImageSize{Megapixels: 12}, false},
+		{"1920x1080", ImageSize{Width: 1920, Height: 1080}, false},
+		{"800 X 600", ImageSize{Width: 800, Height: 600}, false},
+		{">0MP", ImageSize{}, true},
+		{"0x600", ImageSize{}, true},
+		{"huge", ImageSize{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseImageSize(tt.value)
+		if (err != nil) != tt.err || got != tt.want {
+			t.Errorf("ParseImageSize(%q) = %+v, %v, want %+v, error %v", tt.value, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	tests := []struct {
+		value string
+		want  Since
+		err   bool
+	}{
+		{"", Since{}, false},
+		{"24h", Since{Count: 24, Unit: "h"}, false},
+		{"2W", Since{Count: 2, Unit: "w"}, false},
+		{"2023-01-01", Since{Date: time.Date(2023, 1, 1, 0, 0, 0, 0, time.Local)}, false},
+		{"0d", Since{}, true},
+		{"7 fortnights", Since{}, true},
+		{"2023-13-01", Since{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseSince(tt.value)
+		if (err != nil) != tt.err || !got.Date.Equal(tt.want.Date) || got.Count != tt.want.Count || got.Unit != tt.want.Unit {
+			t.Errorf("ParseSince(%q) = %+v, %v, want %+v, error %v", tt.value, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestParseFormats(t *testing.T) {
+	got, err := ParseFormats(" JPEG, .png,jpg,,tif ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"jpg", "png", "tiff"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if _, err := ParseFormats("jpg,psd"); err == nil {
+		t.Error("accepted psd")
+	}
+}
+
+func TestParseChoices(t *testing.T) {
+	parsers := map[string]func(string) (string, error){
+		"color":      ParseColor,
+		"type":       ParseImageType,
+		"license":    ParseLicense,
+		"safesearch": ParseSafeSearch,
+		"aspect":     ParseAspect,
+		"content":    ParseContent,
+	}
+	tests := []struct {
+		parser string
+		value  string
+		want   string
+		err    bool
+	}{
+		{"color", " Red ", "red", false},
+		{"color", "magenta", "", true},
+		{"type", "LineArt", "lineart", false},
+		{"type", "vector", "", true},
+		{"license", "CreativeCommons", "creativecommons", false},
+		{"license", "cc", "", true},
+		{"safesearch", "Strict", "strict", false},
+		{"safesearch", "on", "", true},
+		{"aspect", "panoramic", "panoramic", false},
+		{"aspect", "round", "", true},
+		{"content", "Faces", "faces", false},
+		{"content", "cats", "", true},
+	}
+	for _, tt := range tests {
+		got, err := parsers[tt.parser](tt.value)
+		if (err != nil) != tt.err || got != tt.want {
+			t.Errorf("%s %q = %q, %v, want %q, error %v", tt.parser, tt.value, got, err, tt.want, tt.err)
+		}
+	}
+	for name, parse := range parsers {
+		if got, err := parse(""); got != "" || err != nil {
+			t.Errorf("%s of an empty string = %q, %v, want any", name, got, err)
+		}
+	}
+}
+
+func TestMatchesAspect(t *testing.T) {
+	tests := []struct {
+		aspect        string
+		width, height int
+		want          bool
+	}{
+		{"", 100, 10, true},
+		{"wide", 0, 0, true},
+		{"wide", 1600, 900, true},
+		{"wide", 1050, 1000, false},
+		{"tall", 900, 1600, true},
+		{"tall", 1600, 900, false},
+		{"square", 1050, 1000, true},
+		{"square", 1200, 1000, false},
+		{"panoramic", 2000, 1000, true},
+		{"panoramic", 1600, 900, false},
+	}
+	for _, tt := range tests {
+		if got := MatchesAspect(tt.aspect, tt.width, tt.height); got != tt.want {
+			t.Errorf("MatchesAspect(%q, %d, %d) = %v, want %v", tt.aspect, tt.width, tt.height, got, tt.want)
+		}
+	}
+}
+
+func TestImageSizeMinimum(t *testing.T) {
+	tests := []struct {
+		size ImageSize
+		want string
+		ok   bool
+	}{
+		{ImageSize{Class: "large"}, "", false},
+		{ImageSize{Megapixels: 2}, "2mp", true},
+		{ImageSize{Megapixels: 5}, "4mp", true},
+		{ImageSize{Width: 1920, Height: 1080}, "xga", true},
+		{ImageSize{Width: 1024, Height: 768}, "xga", true},
+		{ImageSize{Width: 300, Height: 300}, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := tt.size.minimum()
+		if ok != tt.ok || got.name != tt.want {
+			t.Errorf("%+v: got %q, %v, want %q, %v", tt.size, got.name, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestGoogleTBS(t *testing.T) {
+	tests := []struct {
+		opts Options
+		want string
+	}{
+		{Options{}, ""},
+		{Options{Size: ImageSize{Class: "large"}, Color: "bw", Type: "photo"}, "isz:l,ic:gray,itp:photo"},
+		{Options{Size: ImageSize{Width: 1920, Height: 1080}, Color: "red"}, "isz:lt,islt:xga,ic:specific,isc:red"},
+		{Options{Type: "clipart", Content: "faces", License: "commercial"}, "itp:face,sur:ol"},
+		{Options{Aspect: "panoramic", Formats: []string{"webp"}}, "iar:xw,ift:webp"},
+		{Options{Formats: []string{"jpg", "png"}, Since: Since{Count: 7, Unit: "d"}}, "qdr:d7"},
+		{Options{Formats: []string{"avif"}, Since: Since{Date: time.Date(2023, 3, 9, 0, 0, 0, 0, time.Local)}}, "cdr:1,cd_min:3/9/2023,cd_max:"},
+	}
+	for _, tt := range tests {
+		if got := googleTBS(tt.opts); got != tt.want {
+			t.Errorf("googleTBS(%+v) = %q, want %q", tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestBingQFT(t *testing.T) {
+	tests := []struct {
+		opts Options
+		want string
+	}{
+		{Options{}, ""},
+		{Options{Size: ImageSize{Class: "icon"}, Color: "transparent"}, "+filterui:imagesize-small+filterui:photo-transparent"},
+		{Options{Size: ImageSize{Width: 1920, Height: 1080}, Color: "teal"}, "+filterui:imagesize-custom_1920_1080+filterui:color2-FGcls_TEAL"},
+		{Options{Size: ImageSize{Megapixels: 2}, Type: "lineart"}, "+filterui:imagesize-custom_1600_1200+filterui:photo-linedrawing"},
+		{Options{Content: "faces", License: "creativecommons", Aspect: "panoramic"}, "+filterui:face-face+filterui:licenseType-Any+filterui:aspect-wide"},
+		{Options{Since: Since{Count: 2, Unit: "h"}}, "+filterui:age-lt120"},
+	}
+	for _, tt := range tests {
+		if got := bingQFT(tt.opts); got != tt.want {
+			t.Errorf("bingQFT(%+v) = %q, want %q", tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestYandexFilters(t *testing.T) {
+	tests := []struct {
+		opts Options
+		want url.Values
+	}{
+		{Options{}, url.Values{}},
+		{Options{Size: ImageSize{Width: 1920, Height: 1080}, Color: "purple", Aspect: "tall"}, url.Values{"isize": {"large"}, "icolor": {"violet"}, "iorient": {"vertical"}}},
+		{Options{Size: ImageSize{Width: 800, Height: 600}, Color: "pink"}, url.Values{}},
+		{Options{Formats: []string{"png"}, SafeSearch: "strict", Type: "photo"}, url.Values{"itype": {"png"}, "family": {"yes"}, "type": {"photo"}}},
+		{Options{Formats: []string{"png"}, Type: "animated"}, url.Values{"itype": {"gifan"}}},
+		{Options{Content: "faces", Type: "clipart", SafeSearch: "moderate"}, url.Values{"type": {"face"}}},
+	}
+	for _, tt := range tests {
+		params := url.Values{}
+		yandexFilters(params, tt.opts)
+		if !reflect.DeepEqual(params, tt.want) {
+			t.Errorf("yandexFilters(%+v) = %v, want %v", tt.opts, params, tt.want)
+		}
+	}
+}
+
+func TestLanguageRegion(t *testing.T) {
+	tests := []struct {
+		opts           Options
+		market, header string
+	}{
+		{Options{}, "", ""},
+		{Options{Language: "de"}, "", "de,en;q=0.5"},
+		{Options{Language: "DE", Region: "at"}, "de-AT", "de-AT,de;q=0.9,en;q=0.5"},
+		{Options{Language: "en", Region: "gb"}, "en-GB", "en-GB,en;q=0.9"},
+		{Options{Region: "jp"}, "", ""},
+	}
+	for _, tt := range tests {
+		if got := bingMarket(tt.opts); got != tt.market {
+			t.Errorf("bingMarket(%+v) = %q, want %q", tt.opts, got, tt.market)
+		}
+		if got := AcceptLanguage(tt.opts); got != tt.header {
+			t.Errorf("AcceptLanguage(%+v) = %q, want %q", tt.opts, got, tt.header)
+		}
+	}
+}
+
+func TestSearchURLFilters(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{"google", Google{}.SearchURL("cats", Options{}), "https://www.google.com/search?q=cats&tbm=isch&udm=2"},
+		{
+			"google with filters",
+			Google{}.SearchURL("black cats", Options{SafeSearch: "strict", Language: "de", Region: "at", Size: ImageSize{Class: "large"}, Content: "none"}),
+			"https://www.google.com/search?q=black+cats+-people+-person+-portrait+-selfie+-face&tbm=isch&udm=2&safe=active&hl=de&gl=at&tbs=isz%3Al",
+		},
+		{"google moderate", Google{}.SearchURL("cats", Options{SafeSearch: "moderate"}), "https://www.google.com/search?q=cats&tbm=isch&udm=2"},
+		{
+			"bing with filters",
+			Bing{}.SearchURL("cats", Options{SafeSearch: "off", Language: "de", Region: "at", Aspect: "square"}),
+			"https://www.bing.com/images/search?q=cats&adlt=off&mkt=de-AT&qft=+filterui:aspect-square&form=IRFLTR",
+		},
+		{"bing language", Bing{}.SearchURL("cats", Options{Language: "ja"}), "https://www.bing.com/images/search?q=cats&setlang=ja"},
+		{"bing region", Bing{}.SearchURL("cats", Options{Region: "jp"}), "https://www.bing.com/images/search?q=cats&cc=jp"},
+		{
+			"yandex region ID",
+			Yandex{}.SearchURL("cats", Options{Yandex: YandexOptions{Region: "11508"}, Region: "de", Language: "tr", Color: "bw"}),
+			"https://yandex.com/images/search?text=cats&lr=11508&lang=tr&icolor=gray",
+		},
+		{"yandex country", Yandex{}.SearchURL("cats", Options{Region: "TR"}), "https://yandex.com/images/search?text=cats&lr=983"},
+	}
+	for _, tt := range tests {
+		if tt.url != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, tt.url, tt.want)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package searcher
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	Register(Flickr{})
+}
+
+// flickrPageSize is how many photos are requested per page
+const flickrPageSize = 100
+
+// flickrSizes are the Flickr size suffixes from largest to smallest
+var flickrSizes = []string{"o", "k", "h", "l", "c", "z"}
+
+// flickrLicenses maps Flickr license IDs to their names
+var flickrLicenses = map[string]string{
+	"0":  "All Rights Reserved",
+	"1":  "CC BY-NC-SA 2.0",
+	"2":  "CC BY-NC 2.0",
+	"3":  "CC BY-NC-ND 2.0",
+	"4":  "CC BY 2.0",
+	"5":  "CC BY-SA 2.0",
+	"6":  "CC BY-ND 2.0",
+	"7":  "No known copyright restrictions",
+	"8":  "United States Government Work",
+	"9":  "CC0 1.0",
+	"10": "Public Domain Mark 1.0",
+}
+
+// flickrLicenseGroups are the shortcuts accepted by ParseFlickrLicense
+var flickrLicenseGroups = map[string]string{
+	"cc": "1,2,3,4,5,6,9,10",
+	"pd": "7,8,9,10",
+}
+
+// ParseFlickrLicense checks a Flickr license filter, the shortcuts "cc" (Creative Commons) and "pd" (public domain)
+// or comma-separated Flickr license IDs, and returns the license IDs it allows for Options.Flickr.License.
+// An empty string allows any license.
+func ParseFlickrLicense(s string) (string, error) {
+	license := strings.ToLower(strings.TrimSpace(s))
+	if group, ok := flickrLicenseGroups[license]; ok {
+		return group, nil
+	}
+	if license == "" {
+		return "", nil
+	}
+	ids := strings.Split(license, ",")
+	for i, id := range ids {
+		ids[i] = strings.TrimSpace(id)
+		if _, ok := flickrLicenses[ids[i]]; !ok {
+			return "", fmt.Errorf("invalid Flickr license %q: use cc, pd or license IDs 0-10", id)
+		}
+	}
+	return strings.Join(ids, ","), nil
+}
+
+// Flickr searches Flickr photos through the Flickr API.
+// It needs an API key, set as the "flickr" credential or the FLICKR_API_KEY environment variable.
+type Flickr struct{}
+
+// Name returns the engine name
+func (Flickr) Name() string {
+	return "flickr"
+}
+
+// SearchURL returns the flickr.photos.search URL for the query and 1-based page.
+// license is a comma-separated list of Flickr license IDs, empty for any license.
+func (Flickr) SearchURL(query, key, license string, page int) string {
+	params := url.Values{
+		"method":         {"flickr.photos.search"},
+		"api_key":        {key},
+		"text":           {query},
+		"media":          {"photos"},
+		"sort":           {"relevance"},
+		"extras":         {"license,owner_name,url_o,url_k,url_h,url_l,url_c,url_z"},
+		"per_page":       {strconv.Itoa(flickrPageSize)},
+		"page":           {strconv.Itoa(page)},
+		"format":         {"json"},
+		"nojsoncallback": {"1"},
+	}
+	if license != "" {
+		params.Set("license", license)
+	}
+	return "https://api.flickr.com/services/rest/?" + params.Encode()
+}
+
+// flickrResponse is the response of flickr.photos.search
+type flickrResponse struct {
+	Stat    string `json:"stat"`
+	Message string `json:"message"`
+	Photos  struct {
+		Page  int              `json:"page"`
+		Pages int              `json:"pages"`
+		Photo []map[string]any `json:"photo"`
+	} `json:"photos"`
+}
+
+// Search pages through the Flickr photo search results, returning the largest available size of each photo
+func (f Flickr) Search(ctx context.Context, query string, opts Options) ([]Result, error) {
+	key := opts.credential("flickr", "FLICKR_API_KEY")
+	if key == "" {
+		return nil, fmt.Errorf("flickr needs an API key: set FLICKR_API_KEY or pass the flickr credential")
+	}
+
+	var results []Result
+	for page := 1; page <= maxPages(opts); page++ {
+		if opts.Limit > 0 && len(results) >= opts.Limit {
+			break
+		}
+
+		var resp flickrResponse
+		if err := fetchJSON(ctx, opts, f.SearchURL(query, key, opts.Flickr.License, page), nil, &resp); err != nil {
+			return nil, fmt.Errorf("failed to fetch Flickr photos: %v", err)
+		}
+		if resp.Stat != "ok" {
+			return nil, fmt.Errorf("failed to fetch Flickr photos: %s", resp.Message)
+		}
+
+		for _, photo := range resp.Photos.Photo {
+			result, ok := flickrResult(photo)
+			if !ok {
+				continue
+			}
+			result.Engine, result.Query = f.Name(), query
+			results = append(results, result)
+		}
+
+		if page >= resp.Photos.Pages {
+			break
+		}
+	}
+
+	return LimitResults(results, opts.Limit), nil
+}
+
+// FlickrResult builds a result from the largest size listed for the photo
+func flickrResult(photo map[string]any) (Result, bool) {
+	str := func(key string) string {
+		switch v := photo[key].(type) {
+		case string:
+			return v
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64)
+		}
+		return ""
+	}
+
+	for _, size := range flickrSizes {
+		imageURL := str("url_" + size)
+		if imageURL == "" {
+			continue
+		}
+		width, _ := strconv.Atoi(str("width_" + size))
+		height, _ := strconv.Atoi(str("height_" + size))
+		return Result{
+			URL:     imageURL,
+			PageURL: fmt.Sprintf("https://www.flickr.com/photos/%s/%s", str("owner"), str("id")),
+			Title:   str("title"),
+			Width:   width,
+			Height:  height,
+			License: flickrLicenses[str("license")],
+			Author:  str("ownername"),
+		}, true
+	}
+	return Result{}, false
+}
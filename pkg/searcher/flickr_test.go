@@ -0,0 +1,38 @@
+package searcher
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseFlickrLicense(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+		err   bool
+	}{
+		{"", "", false},
+		{"cc", "1,2,3,4,5,6,9,10", false},
+		{" PD ", "7,8,9,10", false},
+		{"4, 5,9", "4,5,9", false},
+		{"11", "", true},
+		{"4,by", "", true},
+		{"4,", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseFlickrLicense(tt.value)
+		if (err != nil) != tt.err || got != tt.want {
+			t.Errorf("ParseFlickrLicense(%q) = %q, %v, want %q, error %v", tt.value, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestFlickrSearchURLLicense(t *testing.T) {
+	url := Flickr{}.SearchURL("cats", "key", "4,5", 2)
+	if !strings.Contains(url, "license=4%2C5") || !strings.Contains(url, "page=2") {
+		t.Errorf("got %s, want the licenses and page in the query", url)
+	}
+	if url := (Flickr{}).SearchURL("cats", "key", "", 1); strings.Contains(url, "license=") {
+		t.Errorf("got %s, want no license filter", url)
+	}
+}
@@ -0,0 +1,140 @@
+package searcher
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+func init() {
+	Register(Giphy{})
+}
+
+// giphyPageSize is the largest page the Giphy API returns
+const giphyPageSize = 50
+
+// Giphy searches animated GIFs through the Giphy API, returning GIF or MP4 renditions depending on
+// Options.AnimationFormat. It needs an API key, set as the "giphy" credential or the GIPHY_API_KEY environment variable.
+type Giphy struct{}
+
+// Name returns the engine name
+func (Giphy) Name() string {
+	return "giphy"
+}
+
+// SearchURL returns the GIF search URL for the query starting at the 0-based offset.
+// Content is limited to the PG-13 rating unless mature is set.
+func (Giphy) SearchURL(query, key string, offset int, mature bool) string {
+	rating := "pg-13"
+	if mature {
+		rating = "r"
+	}
+	return "https://api.giphy.com/v1/gifs/search?" + url.Values{
+		"api_key": {key},
+		"q":       {query},
+		"limit":   {strconv.Itoa(giphyPageSize)},
+		"offset":  {strconv.Itoa(offset)},
+		"rating":  {rating},
+	}.Encode()
+}
+
+// giphyRendition is one of the sizes Giphy encodes a GIF in
+type giphyRendition struct {
+	URL    string  `json:"url"`
+	MP4    string  `json:"mp4"`
+	Width  flexInt `json:"width"`
+	Height flexInt `json:"height"`
+}
+
+// giphyResponse is the response of the GIF search endpoint
+type giphyResponse struct {
+	Data []struct {
+		Title    string `json:"title"`
+		URL      string `json:"url"`
+		Username string `json:"username"`
+		Images   struct {
+			Original    giphyRendition `json:"original"`
+			FixedHeight giphyRendition `json:"fixed_height"`
+		} `json:"images"`
+	} `json:"data"`
+	Pagination struct {
+		TotalCount int `json:"total_count"`
+		Count      int `json:"count"`
+		Offset     int `json:"offset"`
+	} `json:"pagination"`
+	Meta struct {
+		Status int    `json:"status"`
+		Msg    string `json:"msg"`
+	} `json:"meta"`
+}
+
+// Search pages through the Giphy search results.
+// With Options.FullRes the original rendition is returned, otherwise the 200px high one.
+func (g Giphy) Search(ctx context.Context, query string, opts Options) ([]Result, error) {
+	key := opts.credential("giphy", "GIPHY_API_KEY")
+	if key == "" {
+		return nil, fmt.Errorf("giphy needs an API key: set GIPHY_API_KEY or pass the giphy credential")
+	}
+	mp4, err := animationMP4(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []Result
+	offset := 0
+	for page := 0; page < maxPages(opts); page++ {
+		if opts.Limit > 0 && len(results) >= opts.Limit {
+			break
+		}
+
+		var resp giphyResponse
+		if err := fetchJSON(ctx, opts, g.SearchURL(query, key, offset, opts.Mature), nil, &resp); err != nil {
+			return nil, fmt.Errorf("failed to fetch Giphy GIFs: %v", err)
+		}
+
+		for _, gif := range resp.Data {
+			rendition := gif.Images.FixedHeight
+			if opts.FullRes {
+				rendition = gif.Images.Original
+			}
+
+			result := Result{
+				URL:         rendition.URL,
+				PageURL:     gif.URL,
+				Engine:      g.Name(),
+				Query:       query,
+				Title:       gif.Title,
+				Width:       int(rendition.Width),
+				Height:      int(rendition.Height),
+				ContentType: "image/gif",
+				Author:      gif.Username,
+			}
+			if mp4 {
+				result.URL, result.ContentType = rendition.MP4, "video/mp4"
+			}
+			if result.URL != "" {
+				results = append(results, result)
+			}
+		}
+
+		offset = resp.Pagination.Offset + resp.Pagination.Count
+		if resp.Pagination.Count == 0 || offset >= resp.Pagination.TotalCount {
+			break
+		}
+	}
+
+	return LimitResults(results, opts.Limit), nil
+}
+
+// animationMP4 reports whether Options.AnimationFormat asks for MP4 renditions instead of GIFs
+func animationMP4(opts Options) (bool, error) {
+	switch opts.AnimationFormat {
+	case "", "gif":
+		return false, nil
+	case "mp4":
+		return true, nil
+	default:
+		return false, fmt.Errorf("invalid animation format %q, expected gif or mp4", opts.AnimationFormat)
+	}
+}
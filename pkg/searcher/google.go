@@ -0,0 +1,145 @@
+package searcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	Register(Google{})
+}
+
+// Google searches Google Images with a headless browser
+type Google struct{}
+
+// googleScroll counts the images on the page that pass filterGoogleImageURLs and clicks "Show more results"
+var googleScroll = scrollStrategy{
+	countJS: `Array.from(document.querySelectorAll('img')).map(img => img.src).filter(src => src.startsWith('https') && !src.includes('google') && !src.includes('base64') && !src.includes('FAVICON')).length`,
+	moreJS:  `(() => { const b = document.querySelector('input.mye4qd, input[type="button"][value*="more" i]') || Array.from(document.querySelectorAll('[role="button"], button, a')).find(e => /^\s*show more results\s*$/i.test(e.textContent)); if (b && b.offsetParent !== null) { b.click(); return true; } return false; })()`,
+}
+
+// Name returns the engine name
+func (Google) Name() string {
+	return "google"
+}
+
+// UsesBrowser reports that the engine drives a browser
+func (Google) UsesBrowser() bool {
+	return true
+}
+
+// SearchURL returns the Google image search page URL for the query, with the filters in opts as the tbs parameter
+// and opts.Language and opts.Region as the hl and gl parameters
+func (Google) SearchURL(query string, opts Options) string {
+	searchURL := fmt.Sprintf("https://www.google.com/search?q=%s&tbm=isch&udm=2", url.QueryEscape(filteredQuery(query, opts)))
+	if safe := googleSafeSearch[opts.SafeSearch]; safe != "" {
+		searchURL += "&safe=" + safe
+	}
+	if opts.Language != "" {
+		searchURL += "&hl=" + url.QueryEscape(opts.Language)
+	}
+	if opts.Region != "" {
+		searchURL += "&gl=" + url.QueryEscape(opts.Region)
+	}
+	if tbs := googleTBS(opts); tbs != "" {
+		searchURL += "&tbs=" + url.QueryEscape(tbs)
+	}
+	return searchURL
+}
+
+// Search searches for images on Google using chromedp and returns the image URLs.
+// With opts.FullRes the original image URLs are read from the metadata Google embeds in the page,
+// otherwise the thumbnails shown on the results page are returned.
+func (g Google) Search(ctx context.Context, query string, opts Options) ([]Result, error) {
+	var imageURLs []string
+	var html string
+	searchURL := g.SearchURL(query, opts)
+
+	tab, err := openTab(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer tab.Close()
+
+	// Load the Google image search page and scroll down to load more images (simulate user interaction)
+	err = loadResultsPage(ctx, tab, g.Name(), searchURL, opts)
+	if err == nil {
+		err = scrollPage(ctx, tab, googleScroll, 10, opts)
+	}
+	if err == nil {
+		// Wait for additional images to load
+		err = sleep(ctx, 2*time.Second)
+	}
+	if err == nil {
+		// Extract thumbnail URLs from the page (use 'src' from 'img' elements)
+		err = tab.Evaluate(`Array.from(document.querySelectorAll('img')).map(img => img.src)`, &imageURLs)
+	}
+	if err == nil {
+		// Keep the page source, which embeds the metadata of every result including the original URL
+		html, err = tab.HTML()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch Google images: %w", err)
+	}
+
+	if captured := capturedResults(tab, g.Name(), query, searchURL); captured != nil {
+		return LimitResults(captured, opts.Limit), nil
+	}
+
+	if opts.FullRes {
+		results := parseGoogleFullResResults(html, g.Name(), query, searchURL)
+		if len(results) > 0 {
+			return LimitResults(results, opts.Limit), nil
+		}
+		slog.Info("No full-resolution Google images found, falling back to thumbnails", "query", query)
+	}
+
+	// Filter out irrelevant images (Google logos, base64 images, favicon images, etc.)
+	filteredImageURLs := filterGoogleImageURLs(imageURLs)
+	return LimitResults(newResults(filteredImageURLs, g.Name(), query, searchURL), opts.Limit), nil
+}
+
+// googleMetadataPattern matches the [url, height, width] arrays in Google's embedded result metadata
+var googleMetadataPattern = regexp.MustCompile(`\["(https?://[^"]+)",(\d+),(\d+)\]`)
+
+// ParseGoogleFullResResults extracts the original image URLs and their dimensions from the page source.
+// Every result carries a thumbnail entry hosted on gstatic.com followed by the original image entry; only the latter is kept.
+func parseGoogleFullResResults(html, engine, query, pageURL string) []Result {
+	var results []Result
+	seen := make(map[string]bool)
+	for _, match := range googleMetadataPattern.FindAllStringSubmatch(html, -1) {
+		// The URL is a JSON string literal, so escapes like \u003d have to be decoded
+		var imageURL string
+		if err := json.Unmarshal([]byte(`"`+match[1]+`"`), &imageURL); err != nil {
+			continue
+		}
+		if strings.Contains(imageURL, "gstatic.com") || seen[imageURL] {
+			continue
+		}
+		seen[imageURL] = true
+
+		height, _ := strconv.Atoi(match[2])
+		width, _ := strconv.Atoi(match[3])
+		results = append(results, Result{URL: imageURL, PageURL: pageURL, Engine: engine, Query: query, Width: width, Height: height})
+	}
+	return results
+}
+
+// Filter out irrelevant Google image URLs (like Google logos, base64 images, and favicon images)
+func filterGoogleImageURLs(imageURLs []string) []string {
+	var filtered []string
+	for _, url := range imageURLs {
+		// Filter out small icons, base64 images, favicon images, and irrelevant URLs
+		if strings.HasPrefix(url, "https") && !strings.Contains(url, "google") && !strings.Contains(url, "base64") && !strings.Contains(url, "FAVICON") {
+			filtered = append(filtered, url)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,102 @@
+package searcher
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+func init() {
+	Register(GoogleAPI{})
+}
+
+const (
+	// googleAPIPageSize is the largest page the Custom Search JSON API returns
+	googleAPIPageSize = 10
+	// googleAPIMaxResults is how deep the Custom Search JSON API lets a query page
+	googleAPIMaxResults = 100
+)
+
+// GoogleAPI searches Google Images through the Custom Search JSON API.
+// It needs an API key and a Programmable Search Engine ID, set as the "google-api" and "google-cx" credentials
+// or the GOOGLE_API_KEY and GOOGLE_CX environment variables.
+type GoogleAPI struct{}
+
+// Name returns the engine name
+func (GoogleAPI) Name() string {
+	return "google-api"
+}
+
+// SearchURL returns the Custom Search JSON API URL for the query, starting at the 1-based result index
+func (GoogleAPI) SearchURL(query, key, cx string, start int) string {
+	return "https://www.googleapis.com/customsearch/v1?" + url.Values{
+		"key":        {key},
+		"cx":         {cx},
+		"q":          {query},
+		"searchType": {"image"},
+		"num":        {strconv.Itoa(googleAPIPageSize)},
+		"start":      {strconv.Itoa(start)},
+	}.Encode()
+}
+
+// googleAPIResponse is the response of the customsearch/v1 endpoint
+type googleAPIResponse struct {
+	Items []struct {
+		Link  string `json:"link"`
+		Title string `json:"title"`
+		Mime  string `json:"mime"`
+		Image struct {
+			ContextLink string `json:"contextLink"`
+			Width       int    `json:"width"`
+			Height      int    `json:"height"`
+		} `json:"image"`
+	} `json:"items"`
+	Queries struct {
+		NextPage []struct {
+			StartIndex int `json:"startIndex"`
+		} `json:"nextPage"`
+	} `json:"queries"`
+}
+
+// Search pages through the Custom Search JSON API image results
+func (g GoogleAPI) Search(ctx context.Context, query string, opts Options) ([]Result, error) {
+	key := opts.credential("google-api", "GOOGLE_API_KEY")
+	cx := opts.credential("google-cx", "GOOGLE_CX")
+	if key == "" || cx == "" {
+		return nil, fmt.Errorf("google-api needs an API key and search engine ID: set GOOGLE_API_KEY and GOOGLE_CX or pass the google-api and google-cx credentials")
+	}
+
+	var results []Result
+	start := 1
+	for page := 0; page < maxPages(opts) && start <= googleAPIMaxResults-googleAPIPageSize+1; page++ {
+		if opts.Limit > 0 && len(results) >= opts.Limit {
+			break
+		}
+
+		var resp googleAPIResponse
+		if err := fetchJSON(ctx, opts, g.SearchURL(query, key, cx, start), nil, &resp); err != nil {
+			return nil, fmt.Errorf("failed to fetch Google API images: %v", err)
+		}
+
+		for _, item := range resp.Items {
+			results = append(results, Result{
+				URL:         item.Link,
+				PageURL:     item.Image.ContextLink,
+				Engine:      g.Name(),
+				Query:       query,
+				Title:       item.Title,
+				Width:       item.Image.Width,
+				Height:      item.Image.Height,
+				ContentType: item.Mime,
+			})
+		}
+
+		if len(resp.Queries.NextPage) == 0 {
+			break
+		}
+		start = resp.Queries.NextPage[0].StartIndex
+	}
+
+	return LimitResults(results, opts.Limit), nil
+}
@@ -0,0 +1,143 @@
+package searcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// httpClient is used by engines that query search endpoints directly instead of driving a browser
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// UserAgent is sent with direct HTTP requests, since several endpoints and image hosts reject Go's default one
+const UserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/129.0.0.0 Safari/537.36"
+
+// defaultPages is how many result pages API engines fetch when not paginating
+const defaultPages = 5
+
+// maxPages returns how many result pages an API engine may fetch for the options
+func maxPages(opts Options) int {
+	if opts.Paginate {
+		return opts.MaxDepth
+	}
+	return defaultPages
+}
+
+// flexInt decodes a JSON number that some endpoints send as a string
+type flexInt int
+
+func (n *flexInt) UnmarshalJSON(data []byte) error {
+	var number json.Number
+	if string(data) != `""` {
+		if err := json.Unmarshal(data, &number); err != nil {
+			return err
+		}
+	}
+	if number == "" {
+		*n = 0
+		return nil
+	}
+	value, err := strconv.Atoi(string(number))
+	if err != nil {
+		return err
+	}
+	*n = flexInt(value)
+	return nil
+}
+
+// proxyClients caches the client of every proxy used, so connections to it are reused across requests
+var proxyClients sync.Map
+
+// client returns the HTTP client for requests made with the options, going through opts.Proxy if set
+func (o Options) client() (*http.Client, error) {
+	if o.Proxy == "" {
+		return httpClient, nil
+	}
+	if client, ok := proxyClients.Load(o.Proxy); ok {
+		return client.(*http.Client), nil
+	}
+
+	proxyURL, err := url.Parse(o.Proxy)
+	if err != nil || proxyURL.Host == "" {
+		return nil, fmt.Errorf("invalid proxy %q", o.Proxy)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyURL(proxyURL)
+	client, _ := proxyClients.LoadOrStore(o.Proxy, &http.Client{Transport: transport, Timeout: httpClient.Timeout})
+	return client.(*http.Client), nil
+}
+
+// fetch performs a GET request with the given extra headers and returns the response body
+func fetch(ctx context.Context, opts Options, url string, header http.Header) ([]byte, error) {
+	client, err := opts.client()
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", UserAgent)
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s from %s", resp.Status, req.URL.Host)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+// fetchJSON performs a GET request and decodes the JSON response into v
+func fetchJSON(ctx context.Context, opts Options, url string, header http.Header, v any) error {
+	body, err := fetch(ctx, opts, url, header)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to decode response: %v", err)
+	}
+	return nil
+}
+
+// postFormJSON posts the form values and decodes the JSON response into v
+func postFormJSON(ctx context.Context, opts Options, url string, form url.Values, v any) error {
+	client, err := opts.client()
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", UserAgent)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s from %s", resp.Status, req.URL.Host)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package searcher
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+func init() {
+	Register(Imgur{})
+}
+
+// Imgur searches the Imgur gallery through the Imgur API, by query or by tag with Options.Imgur.Tag.
+// Albums are expanded into all of their images. It needs a client ID, set as the "imgur" credential or the
+// IMGUR_CLIENT_ID environment variable.
+type Imgur struct{}
+
+// Name returns the engine name
+func (Imgur) Name() string {
+	return "imgur"
+}
+
+// SearchURL returns the gallery search URL for the query, or the gallery tag URL when tag is set, for the 0-based page
+func (Imgur) SearchURL(query string, tag bool, page int) string {
+	if tag {
+		return fmt.Sprintf("https://api.imgur.com/3/gallery/t/%s/top/all/%d", url.PathEscape(strings.TrimPrefix(query, "#")), page)
+	}
+	return fmt.Sprintf("https://api.imgur.com/3/gallery/search/top/all/%d?%s", page, url.Values{"q": {query}}.Encode())
+}
+
+// imgurImage is an image in the Imgur API
+type imgurImage struct {
+	Link        string `json:"link"`
+	Type        string `json:"type"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	Description string `json:"description"`
+}
+
+// imgurItem is a gallery item, which is either a single image or an album
+type imgurItem struct {
+	imgurImage
+	ID          string       `json:"id"`
+	Title       string       `json:"title"`
+	AccountURL  string       `json:"account_url"`
+	IsAlbum     bool         `json:"is_album"`
+	ImagesCount int          `json:"images_count"`
+	Images      []imgurImage `json:"images"`
+}
+
+// imgurSearchResponse is the response of the gallery search endpoint
+type imgurSearchResponse struct {
+	Data []imgurItem `json:"data"`
+}
+
+// imgurTagResponse is the response of the gallery tag endpoint
+type imgurTagResponse struct {
+	Data struct {
+		Items []imgurItem `json:"items"`
+	} `json:"data"`
+}
+
+// imgurAlbumResponse is the response of the album images endpoint
+type imgurAlbumResponse struct {
+	Data []imgurImage `json:"data"`
+}
+
+// Search pages through the gallery results and returns every image, expanding albums
+func (i Imgur) Search(ctx context.Context, query string, opts Options) ([]Result, error) {
+	clientID := opts.credential("imgur", "IMGUR_CLIENT_ID")
+	if clientID == "" {
+		return nil, fmt.Errorf("imgur needs a client ID: set IMGUR_CLIENT_ID or pass the imgur credential")
+	}
+	header := http.Header{"Authorization": {"Client-ID " + clientID}}
+
+	var results []Result
+	for page := 0; page < maxPages(opts); page++ {
+		if opts.Limit > 0 && len(results) >= opts.Limit {
+			break
+		}
+
+		var items []imgurItem
+		if opts.Imgur.Tag {
+			var resp imgurTagResponse
+			if err := fetchJSON(ctx, opts, i.SearchURL(query, true, page), header, &resp); err != nil {
+				return nil, fmt.Errorf("failed to fetch Imgur gallery: %v", err)
+			}
+			items = resp.Data.Items
+		} else {
+			var resp imgurSearchResponse
+			if err := fetchJSON(ctx, opts, i.SearchURL(query, false, page), header, &resp); err != nil {
+				return nil, fmt.Errorf("failed to fetch Imgur gallery: %v", err)
+			}
+			items = resp.Data
+		}
+		if len(items) == 0 {
+			break
+		}
+
+		for _, item := range items {
+			images := []imgurImage{item.imgurImage}
+			if item.IsAlbum {
+				images = item.Images
+				// Search results only include the first few images of large albums
+				if len(images) < item.ImagesCount {
+					var album imgurAlbumResponse
+					albumURL := fmt.Sprintf("https://api.imgur.com/3/album/%s/images", url.PathEscape(item.ID))
+					if err := fetchJSON(ctx, opts, albumURL, header, &album); err == nil {
+						images = album.Data
+					}
+				}
+			}
+
+			for _, image := range images {
+				if image.Link == "" {
+					continue
+				}
+				title := item.Title
+				if title == "" {
+					title = image.Description
+				}
+				results = append(results, Result{
+					URL:         image.Link,
+					PageURL:     "https://imgur.com/gallery/" + item.ID,
+					Engine:      i.Name(),
+					Query:       query,
+					Title:       title,
+					Width:       image.Width,
+					Height:      image.Height,
+					ContentType: image.Type,
+					Author:      item.AccountURL,
+				})
+			}
+		}
+	}
+
+	return LimitResults(results, opts.Limit), nil
+}
@@ -0,0 +1,98 @@
+package searcher
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+func init() {
+	Register(MetMuseum{})
+}
+
+// metObjectsPerPage is how many objects count as one page of results, since the search endpoint returns
+// every matching object ID at once and each object has to be fetched separately
+const metObjectsPerPage = 20
+
+// MetMuseum searches the Metropolitan Museum of Art Open Access collection for public-domain artworks.
+// With Options.FullRes the primary image is returned, otherwise its web-sized version.
+type MetMuseum struct{}
+
+// Name returns the engine name
+func (MetMuseum) Name() string {
+	return "met"
+}
+
+// SearchURL returns the collection search URL for the query, restricted to objects with images
+func (MetMuseum) SearchURL(query string) string {
+	return "https://collectionapi.metmuseum.org/public/collection/v1/search?" + url.Values{
+		"q":         {query},
+		"hasImages": {"true"},
+	}.Encode()
+}
+
+// metSearchResponse is the response of the search endpoint
+type metSearchResponse struct {
+	Total     int   `json:"total"`
+	ObjectIDs []int `json:"objectIDs"`
+}
+
+// metObject is the response of the object endpoint
+type metObject struct {
+	PrimaryImage      string `json:"primaryImage"`
+	PrimaryImageSmall string `json:"primaryImageSmall"`
+	Title             string `json:"title"`
+	ArtistDisplayName string `json:"artistDisplayName"`
+	ObjectURL         string `json:"objectURL"`
+	IsPublicDomain    bool   `json:"isPublicDomain"`
+}
+
+// Search looks up the matching object IDs and fetches the public-domain objects among them
+func (m MetMuseum) Search(ctx context.Context, query string, opts Options) ([]Result, error) {
+	var search metSearchResponse
+	if err := fetchJSON(ctx, opts, m.SearchURL(query), nil, &search); err != nil {
+		return nil, fmt.Errorf("failed to search the Met collection: %v", err)
+	}
+
+	// Objects without a public-domain image are skipped, so the number of lookups is bounded separately
+	lookups := maxPages(opts) * metObjectsPerPage
+	var results []Result
+	for i, id := range search.ObjectIDs {
+		if i >= lookups || (opts.Limit > 0 && len(results) >= opts.Limit) {
+			break
+		}
+
+		var object metObject
+		objectURL := fmt.Sprintf("https://collectionapi.metmuseum.org/public/collection/v1/objects/%d", id)
+		if err := fetchJSON(ctx, opts, objectURL, nil, &object); err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		if !object.IsPublicDomain {
+			continue
+		}
+
+		imageURL := object.PrimaryImageSmall
+		if opts.FullRes || imageURL == "" {
+			imageURL = object.PrimaryImage
+		}
+		if imageURL == "" {
+			continue
+		}
+
+		results = append(results, Result{
+			URL:        imageURL,
+			PageURL:    object.ObjectURL,
+			Engine:     m.Name(),
+			Query:      query,
+			Title:      object.Title,
+			License:    "CC0",
+			LicenseURL: "https://creativecommons.org/publicdomain/zero/1.0/",
+			Author:     object.ArtistDisplayName,
+		})
+	}
+
+	return LimitResults(results, opts.Limit), nil
+}
@@ -0,0 +1,135 @@
+package searcher
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	Register(NASA{})
+}
+
+// nasaPageSize is the largest page the NASA Image and Video Library returns
+const nasaPageSize = 100
+
+// NASA searches the NASA Image and Video Library (images-api.nasa.gov), returning the original files
+type NASA struct{}
+
+// Name returns the engine name
+func (NASA) Name() string {
+	return "nasa"
+}
+
+// SearchURL returns the image search URL for the query and 1-based page
+func (NASA) SearchURL(query string, page int) string {
+	return "https://images-api.nasa.gov/search?" + url.Values{
+		"q":          {query},
+		"media_type": {"image"},
+		"page":       {strconv.Itoa(page)},
+		"page_size":  {strconv.Itoa(nasaPageSize)},
+	}.Encode()
+}
+
+// nasaResponse is the response of the search endpoint
+type nasaResponse struct {
+	Collection struct {
+		Items []struct {
+			Href string `json:"href"`
+			Data []struct {
+				NasaID       string `json:"nasa_id"`
+				Title        string `json:"title"`
+				Description  string `json:"description"`
+				Center       string `json:"center"`
+				Photographer string `json:"photographer"`
+			} `json:"data"`
+			Links []struct {
+				Href string `json:"href"`
+				Rel  string `json:"rel"`
+			} `json:"links"`
+		} `json:"items"`
+		Links []struct {
+			Rel string `json:"rel"`
+		} `json:"links"`
+	} `json:"collection"`
+}
+
+// Search pages through the search results and resolves each item to its original file
+func (n NASA) Search(ctx context.Context, query string, opts Options) ([]Result, error) {
+	var results []Result
+	for page := 1; page <= maxPages(opts); page++ {
+		if opts.Limit > 0 && len(results) >= opts.Limit {
+			break
+		}
+
+		var resp nasaResponse
+		if err := fetchJSON(ctx, opts, n.SearchURL(query, page), nil, &resp); err != nil {
+			return nil, fmt.Errorf("failed to fetch NASA images: %v", err)
+		}
+
+		for _, item := range resp.Collection.Items {
+			if len(item.Data) == 0 {
+				continue
+			}
+			if opts.Limit > 0 && len(results) >= opts.Limit {
+				break
+			}
+			data := item.Data[0]
+
+			imageURL := nasaOriginalURL(ctx, opts, item.Href)
+			if imageURL == "" {
+				// Fall back to the preview when the asset manifest is unavailable
+				for _, link := range item.Links {
+					if link.Rel == "preview" {
+						imageURL = link.Href
+					}
+				}
+			}
+			if imageURL == "" {
+				continue
+			}
+
+			author := data.Photographer
+			if author == "" {
+				author = "NASA " + data.Center
+			}
+			results = append(results, Result{
+				URL:         imageURL,
+				PageURL:     "https://images.nasa.gov/details/" + url.PathEscape(data.NasaID),
+				Engine:      n.Name(),
+				Query:       query,
+				Title:       data.Title,
+				Description: data.Description,
+				License:     "Public Domain",
+				Author:      strings.TrimSpace(author),
+			})
+		}
+
+		hasNext := false
+		for _, link := range resp.Collection.Links {
+			hasNext = hasNext || link.Rel == "next"
+		}
+		if !hasNext {
+			break
+		}
+	}
+
+	return LimitResults(results, opts.Limit), nil
+}
+
+// nasaOriginalURL reads the asset manifest of an item, a JSON list of file URLs, and returns the original file
+func nasaOriginalURL(ctx context.Context, opts Options, manifestURL string) string {
+	var files []string
+	if manifestURL == "" || fetchJSON(ctx, opts, manifestURL, nil, &files) != nil {
+		return ""
+	}
+	for _, file := range files {
+		if strings.Contains(file, "~orig.") {
+			// Manifests list plain http URLs for files that are served over https as well
+			return strings.Replace(file, "http://", "https://", 1)
+		}
+	}
+	return ""
+}
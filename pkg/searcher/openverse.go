@@ -0,0 +1,114 @@
+package searcher
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	Register(Openverse{})
+}
+
+// openversePageSize is the largest page the Openverse API returns to anonymous clients
+const openversePageSize = 20
+
+// Openverse searches openly licensed images through the Openverse API.
+// Anonymous access works with a low rate limit; an access token can be set as the "openverse" credential
+// or the OPENVERSE_TOKEN environment variable.
+type Openverse struct{}
+
+// Name returns the engine name
+func (Openverse) Name() string {
+	return "openverse"
+}
+
+// SearchURL returns the v1/images URL for the query and 1-based page
+func (Openverse) SearchURL(query string, page int) string {
+	return "https://api.openverse.org/v1/images/?" + url.Values{
+		"q":         {query},
+		"page":      {strconv.Itoa(page)},
+		"page_size": {strconv.Itoa(openversePageSize)},
+	}.Encode()
+}
+
+// openverseResponse is the response of the v1/images endpoint
+type openverseResponse struct {
+	PageCount int `json:"page_count"`
+	Results   []struct {
+		URL               string `json:"url"`
+		ForeignLandingURL string `json:"foreign_landing_url"`
+		Title             string `json:"title"`
+		Creator           string `json:"creator"`
+		License           string `json:"license"`
+		LicenseVersion    string `json:"license_version"`
+		LicenseURL        string `json:"license_url"`
+		Width             int    `json:"width"`
+		Height            int    `json:"height"`
+		Filetype          string `json:"filetype"`
+	} `json:"results"`
+}
+
+// Search pages through the Openverse image search results
+func (o Openverse) Search(ctx context.Context, query string, opts Options) ([]Result, error) {
+	var header http.Header
+	if token := opts.credential("openverse", "OPENVERSE_TOKEN"); token != "" {
+		header = http.Header{"Authorization": {"Bearer " + token}}
+	}
+
+	var results []Result
+	for page := 1; page <= maxPages(opts); page++ {
+		if opts.Limit > 0 && len(results) >= opts.Limit {
+			break
+		}
+
+		var resp openverseResponse
+		if err := fetchJSON(ctx, opts, o.SearchURL(query, page), header, &resp); err != nil {
+			return nil, fmt.Errorf("failed to fetch Openverse images: %v", err)
+		}
+
+		for _, image := range resp.Results {
+			results = append(results, Result{
+				URL:         image.URL,
+				PageURL:     image.ForeignLandingURL,
+				Engine:      o.Name(),
+				Query:       query,
+				Title:       image.Title,
+				Width:       image.Width,
+				Height:      image.Height,
+				ContentType: mimeFromFormat(image.Filetype),
+				License:     openverseLicense(image.License, image.LicenseVersion),
+				LicenseURL:  image.LicenseURL,
+				Author:      image.Creator,
+			})
+		}
+
+		if page >= resp.PageCount {
+			break
+		}
+	}
+
+	return LimitResults(results, opts.Limit), nil
+}
+
+// openverseLicense turns an Openverse license code and version into a readable name, e.g. "CC BY-SA 4.0"
+func openverseLicense(code, version string) string {
+	var name string
+	switch code {
+	case "":
+		return ""
+	case "cc0":
+		name = "CC0"
+	case "pdm":
+		name = "Public Domain Mark"
+	default:
+		name = "CC " + strings.ToUpper(code)
+	}
+	if version != "" {
+		name += " " + version
+	}
+	return name
+}
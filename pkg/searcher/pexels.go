@@ -0,0 +1,105 @@
+package searcher
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+func init() {
+	Register(Pexels{})
+}
+
+// pexelsPageSize is the largest page the Pexels API returns
+const pexelsPageSize = 80
+
+// Pexels searches photos through the Pexels API.
+// It needs an API key, set as the "pexels" credential or the PEXELS_API_KEY environment variable.
+type Pexels struct{}
+
+// Name returns the engine name
+func (Pexels) Name() string {
+	return "pexels"
+}
+
+// SearchURL returns the v1/search URL for the query and 1-based page
+func (Pexels) SearchURL(query string, page int, opts Options) string {
+	params := url.Values{
+		"query":    {query},
+		"page":     {strconv.Itoa(page)},
+		"per_page": {strconv.Itoa(pexelsPageSize)},
+	}
+	if opts.Orientation != "" {
+		params.Set("orientation", opts.Orientation)
+	}
+	return "https://api.pexels.com/v1/search?" + params.Encode()
+}
+
+// pexelsResponse is the response of the v1/search endpoint
+type pexelsResponse struct {
+	NextPage string `json:"next_page"`
+	Photos   []struct {
+		Width        int    `json:"width"`
+		Height       int    `json:"height"`
+		URL          string `json:"url"`
+		Alt          string `json:"alt"`
+		Photographer string `json:"photographer"`
+		Src          struct {
+			Original string `json:"original"`
+			Large    string `json:"large"`
+			Medium   string `json:"medium"`
+		} `json:"src"`
+	} `json:"photos"`
+}
+
+// Search pages through the Pexels photo search results, returning the rendition picked by opts.Pexels.Size
+func (p Pexels) Search(ctx context.Context, query string, opts Options) ([]Result, error) {
+	key := opts.credential("pexels", "PEXELS_API_KEY")
+	if key == "" {
+		return nil, fmt.Errorf("pexels needs an API key: set PEXELS_API_KEY or pass the pexels credential")
+	}
+	header := http.Header{"Authorization": {key}}
+
+	var results []Result
+	for page := 1; page <= maxPages(opts); page++ {
+		if opts.Limit > 0 && len(results) >= opts.Limit {
+			break
+		}
+
+		var resp pexelsResponse
+		if err := fetchJSON(ctx, opts, p.SearchURL(query, page, opts), header, &resp); err != nil {
+			return nil, fmt.Errorf("failed to fetch Pexels photos: %v", err)
+		}
+
+		for _, photo := range resp.Photos {
+			// Only the original carries the dimensions reported by the API
+			imageURL, width, height := photo.Src.Original, photo.Width, photo.Height
+			switch opts.Pexels.Size {
+			case "large":
+				imageURL, width, height = photo.Src.Large, 0, 0
+			case "medium":
+				imageURL, width, height = photo.Src.Medium, 0, 0
+			}
+
+			results = append(results, Result{
+				URL:     imageURL,
+				PageURL: photo.URL,
+				Engine:  p.Name(),
+				Query:   query,
+				Title:   photo.Alt,
+				Width:   width,
+				Height:  height,
+				License: "Pexels License",
+				Author:  photo.Photographer,
+			})
+		}
+
+		if resp.NextPage == "" {
+			break
+		}
+	}
+
+	return LimitResults(results, opts.Limit), nil
+}
@@ -0,0 +1,104 @@
+package searcher
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+)
+
+func init() {
+	Register(Pinterest{})
+}
+
+// pinterestScroll counts the pin images collected so far; Pinterest loads more pins on scroll without a button
+var pinterestScroll = scrollStrategy{
+	countJS: `window.__pinImages ? window.__pinImages.size : 0`,
+}
+
+// pinterestCollectJS records every pin image that appears on the page. Pinterest removes pins that scroll out of view,
+// so they have to be collected while scrolling instead of once at the end.
+const pinterestCollectJS = `(() => {
+	window.__pinImages = window.__pinImages || new Set();
+	const collect = () => document.querySelectorAll('img[src*="i.pinimg.com"]').forEach(img => window.__pinImages.add(img.src));
+	collect();
+	new MutationObserver(collect).observe(document.body, {childList: true, subtree: true});
+})()`
+
+var (
+	// pinterestOrigPattern matches the original image URLs in the pin data embedded in the page source
+	pinterestOrigPattern = regexp.MustCompile(`"orig":\{[^}]*?"url":"([^"]+)"`)
+	// pinterestSizePattern matches the size segment of a pin image URL, e.g. /236x/
+	pinterestSizePattern = regexp.MustCompile(`^(https://i\.pinimg\.com)/[^/]+/`)
+)
+
+// Pinterest searches Pinterest pins with a headless browser. Anonymous results are heavily limited,
+// so it works best with a logged-in session from Options.Browser.CookieFile or Options.Browser.UserDataDir.
+type Pinterest struct{}
+
+// Name returns the engine name
+func (Pinterest) Name() string {
+	return "pinterest"
+}
+
+// UsesBrowser reports that the engine drives a browser
+func (Pinterest) UsesBrowser() bool {
+	return true
+}
+
+// SearchURL returns the Pinterest pin search page URL for the query
+func (Pinterest) SearchURL(query string) string {
+	return "https://www.pinterest.com/search/pins/?" + url.Values{"q": {query}}.Encode()
+}
+
+// Search searches for pins using chromedp and returns the orig image URLs
+func (p Pinterest) Search(ctx context.Context, query string, opts Options) ([]Result, error) {
+	var pinImages []string
+	var html string
+	searchURL := p.SearchURL(query)
+
+	tab, err := openTab(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer tab.Close()
+
+	// Load the Pinterest pin search page and collect pin images while scrolling down to load more pins
+	err = loadResultsPage(ctx, tab, p.Name(), searchURL, opts)
+	if err == nil {
+		err = tab.Evaluate(pinterestCollectJS, nil)
+	}
+	if err == nil {
+		err = scrollPage(ctx, tab, pinterestScroll, 5, opts)
+	}
+	if err == nil {
+		err = tab.Evaluate(`Array.from(window.__pinImages || [])`, &pinImages)
+	}
+	if err == nil {
+		html, err = tab.HTML()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch Pinterest pins: %w", err)
+	}
+
+	if captured := capturedResults(tab, p.Name(), query, searchURL); captured != nil {
+		return LimitResults(captured, opts.Limit), nil
+	}
+
+	// The embedded pin data names the orig URL directly; pins loaded later only show resized images,
+	// whose original lives under /originals/ with the same path
+	imageURLs := parseEmbeddedURLs(html, pinterestOrigPattern, nil)
+	seen := make(map[string]bool, len(imageURLs))
+	for _, imageURL := range imageURLs {
+		seen[imageURL] = true
+	}
+	for _, pinImage := range pinImages {
+		imageURL := pinterestSizePattern.ReplaceAllString(pinImage, "$1/originals/")
+		if !seen[imageURL] {
+			seen[imageURL] = true
+			imageURLs = append(imageURLs, imageURL)
+		}
+	}
+
+	return LimitResults(newResults(imageURLs, p.Name(), query, searchURL), opts.Limit), nil
+}
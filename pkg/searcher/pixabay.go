@@ -0,0 +1,97 @@
+package searcher
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+func init() {
+	Register(Pixabay{})
+}
+
+// pixabayPageSize is the largest page the Pixabay API returns
+const pixabayPageSize = 200
+
+// Pixabay searches images through the Pixabay API.
+// It needs an API key, set as the "pixabay" credential or the PIXABAY_API_KEY environment variable.
+type Pixabay struct{}
+
+// Name returns the engine name
+func (Pixabay) Name() string {
+	return "pixabay"
+}
+
+// SearchURL returns the Pixabay API URL for the query and 1-based page
+func (Pixabay) SearchURL(query, key string, page int, opts Options) string {
+	params := url.Values{
+		"key":        {key},
+		"q":          {query},
+		"page":       {strconv.Itoa(page)},
+		"per_page":   {strconv.Itoa(pixabayPageSize)},
+		"safesearch": {"false"},
+	}
+	if opts.Pixabay.Category != "" {
+		params.Set("category", opts.Pixabay.Category)
+	}
+	if opts.Pixabay.ImageType != "" {
+		params.Set("image_type", opts.Pixabay.ImageType)
+	}
+	if opts.MinWidth > 0 {
+		params.Set("min_width", strconv.Itoa(opts.MinWidth))
+	}
+	if opts.MinHeight > 0 {
+		params.Set("min_height", strconv.Itoa(opts.MinHeight))
+	}
+	return "https://pixabay.com/api/?" + params.Encode()
+}
+
+// pixabayResponse is the response of the Pixabay API
+type pixabayResponse struct {
+	TotalHits int `json:"totalHits"`
+	Hits      []struct {
+		PageURL       string `json:"pageURL"`
+		LargeImageURL string `json:"largeImageURL"`
+		Tags          string `json:"tags"`
+		User          string `json:"user"`
+	} `json:"hits"`
+}
+
+// Search pages through the Pixabay image search results
+func (p Pixabay) Search(ctx context.Context, query string, opts Options) ([]Result, error) {
+	key := opts.credential("pixabay", "PIXABAY_API_KEY")
+	if key == "" {
+		return nil, fmt.Errorf("pixabay needs an API key: set PIXABAY_API_KEY or pass the pixabay credential")
+	}
+
+	var results []Result
+	for page := 1; page <= maxPages(opts); page++ {
+		if opts.Limit > 0 && len(results) >= opts.Limit {
+			break
+		}
+
+		var resp pixabayResponse
+		if err := fetchJSON(ctx, opts, p.SearchURL(query, key, page, opts), nil, &resp); err != nil {
+			return nil, fmt.Errorf("failed to fetch Pixabay images: %v", err)
+		}
+
+		for _, hit := range resp.Hits {
+			results = append(results, Result{
+				URL:     hit.LargeImageURL,
+				PageURL: hit.PageURL,
+				Engine:  p.Name(),
+				Query:   query,
+				Title:   hit.Tags,
+				License: "Pixabay Content License",
+				Author:  hit.User,
+			})
+		}
+
+		if page*pixabayPageSize >= resp.TotalHits {
+			break
+		}
+	}
+
+	return LimitResults(results, opts.Limit), nil
+}
@@ -0,0 +1,72 @@
+package searcher
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseQuery checks that a query only uses the operators every web search engine understands and returns it with
+// its whitespace normalized. The portable operators are:
+//
+//   - "exact phrase" in double quotes
+//   - -term and -"phrase" to exclude results containing them
+//   - term OR term, in upper case, to match either term
+//   - site:example.com to search a single domain
+//
+// Engines without operator support, such as most APIs, search for the query as plain text.
+func ParseQuery(query string) (string, error) {
+	terms, err := splitQuery(query)
+	if err != nil {
+		return "", err
+	}
+
+	included := false
+	for i, term := range terms {
+		switch {
+		case term == "OR":
+			if i == 0 || i == len(terms)-1 || terms[i-1] == "OR" || terms[i+1] == "OR" {
+				return "", fmt.Errorf("invalid query %q: OR needs a term on both sides", query)
+			}
+		case strings.HasPrefix(term, "-"):
+			if exclusion := strings.Trim(term[1:], `"`); exclusion == "" {
+				return "", fmt.Errorf("invalid query %q: - needs a term to exclude, like -watermark", query)
+			}
+		case term == `""`:
+			return "", fmt.Errorf("invalid query %q: empty phrase", query)
+		default:
+			included = true
+		}
+	}
+	if !included {
+		return "", fmt.Errorf("invalid query %q: there is nothing to search for besides exclusions", query)
+	}
+	return strings.Join(terms, " "), nil
+}
+
+// splitQuery splits a query into terms at whitespace outside of double quotes, keeping the quotes in the terms
+func splitQuery(query string) ([]string, error) {
+	var terms []string
+	var term strings.Builder
+	quoted := false
+	for _, r := range query {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			term.WriteRune(r)
+		case !quoted && (r == ' ' || r == '\t' || r == '\n' || r == '\r'):
+			if term.Len() > 0 {
+				terms = append(terms, term.String())
+				term.Reset()
+			}
+		default:
+			term.WriteRune(r)
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("invalid query %q: unbalanced double quote", query)
+	}
+	if term.Len() > 0 {
+		terms = append(terms, term.String())
+	}
+	return terms, nil
+}
@@ -0,0 +1,88 @@
+package searcher
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+func init() {
+	Register(Qwant{})
+}
+
+// qwantPageSize is the largest page the Qwant images API returns
+const qwantPageSize = 50
+
+// Qwant searches Qwant Images through the JSON API its web app uses, without a browser
+type Qwant struct{}
+
+// Name returns the engine name
+func (Qwant) Name() string {
+	return "qwant"
+}
+
+// SearchURL returns the Qwant images API URL for the query from the given offset
+func (Qwant) SearchURL(query string, offset int) string {
+	return "https://api.qwant.com/v3/search/images?" + url.Values{
+		"q":          {query},
+		"count":      {strconv.Itoa(qwantPageSize)},
+		"offset":     {strconv.Itoa(offset)},
+		"locale":     {"en_US"},
+		"t":          {"images"},
+		"safesearch": {"1"},
+	}.Encode()
+}
+
+// qwantResponse is the response of the search/images endpoint
+type qwantResponse struct {
+	Status string `json:"status"`
+	Data   struct {
+		Result struct {
+			Items []struct {
+				Media  string  `json:"media"`
+				URL    string  `json:"url"`
+				Title  string  `json:"title"`
+				Width  flexInt `json:"width"`
+				Height flexInt `json:"height"`
+			} `json:"items"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// Search pages through the Qwant image results
+func (q Qwant) Search(ctx context.Context, query string, opts Options) ([]Result, error) {
+	var results []Result
+	for page := 0; page < maxPages(opts); page++ {
+		if opts.Limit > 0 && len(results) >= opts.Limit {
+			break
+		}
+
+		var resp qwantResponse
+		if err := fetchJSON(ctx, opts, q.SearchURL(query, page*qwantPageSize), nil, &resp); err != nil {
+			return nil, fmt.Errorf("failed to fetch Qwant images: %v", err)
+		}
+		if resp.Status != "success" {
+			return nil, fmt.Errorf("failed to fetch Qwant images: status %q", resp.Status)
+		}
+
+		items := resp.Data.Result.Items
+		for _, item := range items {
+			results = append(results, Result{
+				URL:     item.Media,
+				PageURL: item.URL,
+				Engine:  q.Name(),
+				Query:   query,
+				Title:   item.Title,
+				Width:   int(item.Width),
+				Height:  int(item.Height),
+			})
+		}
+
+		if len(items) < qwantPageSize {
+			break
+		}
+	}
+
+	return LimitResults(results, opts.Limit), nil
+}
@@ -0,0 +1,146 @@
+package searcher
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+func init() {
+	Register(Reddit{})
+}
+
+// redditPageSize is the largest listing page Reddit returns
+const redditPageSize = 100
+
+// imageExtensions are the file extensions treated as direct image links
+var imageExtensions = map[string]bool{".jpg": true, ".jpeg": true, ".png": true, ".gif": true, ".webp": true}
+
+// Reddit searches image posts through Reddit's public JSON listings, across all of Reddit or within Options.Reddit.Subreddit
+type Reddit struct{}
+
+// Name returns the engine name
+func (Reddit) Name() string {
+	return "reddit"
+}
+
+// SearchURL returns the search.json listing URL for the query, continuing after the given post
+func (Reddit) SearchURL(query, subreddit, after string) string {
+	params := url.Values{
+		"q":     {query},
+		"limit": {fmt.Sprint(redditPageSize)},
+		"type":  {"link"},
+	}
+	if after != "" {
+		params.Set("after", after)
+	}
+	if subreddit != "" {
+		params.Set("restrict_sr", "1")
+		return fmt.Sprintf("https://www.reddit.com/r/%s/search.json?%s", url.PathEscape(subreddit), params.Encode())
+	}
+	return "https://www.reddit.com/search.json?" + params.Encode()
+}
+
+// redditPost is the data of a post in a listing
+type redditPost struct {
+	Title       string `json:"title"`
+	Author      string `json:"author"`
+	URL         string `json:"url"`
+	Permalink   string `json:"permalink"`
+	IsGallery   bool   `json:"is_gallery"`
+	GalleryData *struct {
+		Items []struct {
+			MediaID string `json:"media_id"`
+		} `json:"items"`
+	} `json:"gallery_data"`
+	MediaMetadata map[string]struct {
+		S struct {
+			U string `json:"u"`
+			X int    `json:"x"`
+			Y int    `json:"y"`
+		} `json:"s"`
+	} `json:"media_metadata"`
+}
+
+// redditResponse is a listing response
+type redditResponse struct {
+	Data struct {
+		After    string `json:"after"`
+		Children []struct {
+			Data redditPost `json:"data"`
+		} `json:"children"`
+	} `json:"data"`
+}
+
+// Search pages through the matching posts and returns the direct image URLs they link to
+func (r Reddit) Search(ctx context.Context, query string, opts Options) ([]Result, error) {
+	// Reddit throttles requests that look like anonymous browsers much harder than identified clients
+	header := http.Header{"User-Agent": {"image-searcher/1.0 (https://github.com/selman92/image-searcher)"}}
+
+	var results []Result
+	after := ""
+	for page := 0; page < maxPages(opts); page++ {
+		if opts.Limit > 0 && len(results) >= opts.Limit {
+			break
+		}
+
+		var resp redditResponse
+		if err := fetchJSON(ctx, opts, r.SearchURL(query, opts.Reddit.Subreddit, after), header, &resp); err != nil {
+			return nil, fmt.Errorf("failed to fetch Reddit posts: %v", err)
+		}
+
+		for _, child := range resp.Data.Children {
+			post := child.Data
+			for _, result := range redditImages(post) {
+				result.PageURL = "https://www.reddit.com" + post.Permalink
+				result.Engine, result.Query = r.Name(), query
+				result.Title, result.Author = post.Title, post.Author
+				results = append(results, result)
+			}
+		}
+
+		if resp.Data.After == "" {
+			break
+		}
+		after = resp.Data.After
+	}
+
+	return LimitResults(results, opts.Limit), nil
+}
+
+// redditImages resolves a post to the direct image URLs it links to: every image of a gallery,
+// i.redd.it and other direct image links, and single imgur pages
+func redditImages(post redditPost) []Result {
+	if post.IsGallery && post.GalleryData != nil {
+		var results []Result
+		for _, item := range post.GalleryData.Items {
+			media, ok := post.MediaMetadata[item.MediaID]
+			if !ok || media.S.U == "" {
+				continue
+			}
+			// Gallery URLs are HTML-escaped in the JSON
+			results = append(results, Result{URL: html.UnescapeString(media.S.U), Width: media.S.X, Height: media.S.Y})
+		}
+		return results
+	}
+
+	u, err := url.Parse(post.URL)
+	if err != nil {
+		return nil
+	}
+	ext := strings.ToLower(path.Ext(u.Path))
+	switch {
+	case imageExtensions[ext]:
+		return []Result{{URL: post.URL}}
+	case ext == ".gifv" && strings.HasSuffix(u.Host, "imgur.com"):
+		return []Result{{URL: strings.TrimSuffix(post.URL, path.Ext(u.Path)) + ".gif"}}
+	case (u.Host == "imgur.com" || u.Host == "www.imgur.com") && ext == "" && strings.Count(u.Path, "/") == 1:
+		// A single image page like imgur.com/abc123 serves its image from i.imgur.com; albums and galleries are skipped
+		return []Result{{URL: "https://i.imgur.com" + u.Path + ".jpg"}}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package searcher
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+)
+
+func init() {
+	Register(Sogou{})
+}
+
+// sogouScroll counts the result items on the page; Sogou lazy loads more results on scroll without a button
+var sogouScroll = scrollStrategy{
+	countJS: `document.querySelectorAll('.figure-result-list li, .img-box').length`,
+}
+
+// sogouExtractJS reads the result list from the store state the Sogou page keeps up to date while lazy loading
+const sogouExtractJS = `(() => {
+	const state = window.__INITIAL_STATE__;
+	const list = (state && state.searchList && state.searchList.searchList) || [];
+	return list.map(i => ({url: i.oriPicUrl || i.picUrl || '', thumb: i.thumbUrl || i.picUrl || '', page: i.url || '', title: i.title || '', w: Number(i.width) || 0, h: Number(i.height) || 0}));
+})()`
+
+// sogouOriPicURLPattern matches original image URLs in the JSON embedded in the page source
+var sogouOriPicURLPattern = regexp.MustCompile(`"oriPicUrl":"([^"]+)"`)
+
+// sogouItem is a result extracted by sogouExtractJS
+type sogouItem struct {
+	URL    string `json:"url"`
+	Thumb  string `json:"thumb"`
+	Page   string `json:"page"`
+	Title  string `json:"title"`
+	Width  int    `json:"w"`
+	Height int    `json:"h"`
+}
+
+// Sogou searches Sogou Images with a headless browser
+type Sogou struct{}
+
+// Name returns the engine name
+func (Sogou) Name() string {
+	return "sogou"
+}
+
+// UsesBrowser reports that the engine drives a browser
+func (Sogou) UsesBrowser() bool {
+	return true
+}
+
+// SearchURL returns the Sogou image search page URL for the query
+func (Sogou) SearchURL(query string) string {
+	return "https://pic.sogou.com/pics?" + url.Values{"query": {query}}.Encode()
+}
+
+// Search searches for images on Sogou using chromedp and returns the original image URLs,
+// or the thumbnails when opts.FullRes is off
+func (s Sogou) Search(ctx context.Context, query string, opts Options) ([]Result, error) {
+	var items []sogouItem
+	var html string
+	searchURL := s.SearchURL(query)
+
+	tab, err := openTab(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer tab.Close()
+
+	// Load the Sogou image search page, scroll down to trigger lazy loading (simulate user interaction) and read
+	// the result list
+	err = loadResultsPage(ctx, tab, s.Name(), searchURL, opts)
+	if err == nil {
+		err = scrollPage(ctx, tab, sogouScroll, 5, opts)
+	}
+	if err == nil {
+		err = tab.Evaluate(sogouExtractJS, &items)
+	}
+	if err == nil {
+		html, err = tab.HTML()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch Sogou images: %w", err)
+	}
+
+	if captured := capturedResults(tab, s.Name(), query, searchURL); captured != nil {
+		return LimitResults(captured, opts.Limit), nil
+	}
+
+	var results []Result
+	for _, item := range items {
+		imageURL := item.URL
+		if !opts.FullRes {
+			imageURL = item.Thumb
+		}
+		if imageURL == "" {
+			continue
+		}
+		results = append(results, Result{
+			URL:     imageURL,
+			PageURL: item.Page,
+			Engine:  s.Name(),
+			Query:   query,
+			Title:   item.Title,
+			Width:   item.Width,
+			Height:  item.Height,
+		})
+	}
+
+	// Older page versions only embed the result list in the page source
+	if len(results) == 0 && opts.FullRes {
+		results = newResults(parseEmbeddedURLs(html, sogouOriPicURLPattern, nil), s.Name(), query, searchURL)
+	}
+
+	return LimitResults(results, opts.Limit), nil
+}
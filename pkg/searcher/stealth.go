@@ -0,0 +1,156 @@
+package searcher
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/chromedp/cdproto/browser"
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+// stealthFlags are the command line switches of a started Chrome with Options.Browser.Stealth. They drop the automation
+// banner and the navigator.webdriver flag Chrome sets for automated browsers, use the new headless mode that
+// renders like a regular Chrome, and give the window a common desktop size instead of the headless 800x600.
+var stealthFlags = []chromedp.ExecAllocatorOption{
+	chromedp.Flag("enable-automation", false),
+	chromedp.Flag("disable-blink-features", "AutomationControlled"),
+	chromedp.Flag("headless", "new"),
+	chromedp.WindowSize(1920, 1080),
+}
+
+// stealthScript runs before the scripts of every document in a tab with Options.Browser.Stealth and hides the properties
+// bot detection scripts check to tell a headless or automated Chrome from a regular one
+const stealthScript = `(() => {
+	const define = (object, property, value) => {
+		try {
+			Object.defineProperty(object, property, { get: () => value, configurable: true });
+		} catch (e) {}
+	};
+
+	define(Navigator.prototype, 'webdriver', undefined);
+
+	if (!navigator.languages || navigator.languages.length === 0) {
+		define(Navigator.prototype, 'languages', ['en-US', 'en']);
+	}
+
+	if (navigator.plugins.length === 0) {
+		const names = ['PDF Viewer', 'Chrome PDF Viewer', 'Chromium PDF Viewer', 'Microsoft Edge PDF Viewer', 'WebKit built-in PDF'];
+		const plugins = names.map(name => ({ name, filename: 'internal-pdf-viewer', description: 'Portable Document Format', length: 1 }));
+		plugins.item = i => plugins[i] || null;
+		plugins.namedItem = name => plugins.find(p => p.name === name) || null;
+		plugins.refresh = () => {};
+		Object.setPrototypeOf(plugins, PluginArray.prototype);
+		define(Navigator.prototype, 'plugins', plugins);
+	}
+
+	if (!window.chrome) {
+		window.chrome = {};
+	}
+	if (!window.chrome.runtime) {
+		window.chrome.runtime = {};
+	}
+
+	if (navigator.permissions && navigator.permissions.query) {
+		const query = navigator.permissions.query.bind(navigator.permissions);
+		navigator.permissions.query = parameters => parameters && parameters.name === 'notifications'
+			? Promise.resolve({ state: Notification.permission === 'default' ? 'prompt' : Notification.permission, onchange: null })
+			: query(parameters);
+	}
+
+	for (const context of [window.WebGLRenderingContext, window.WebGL2RenderingContext]) {
+		if (!context) {
+			continue;
+		}
+		const getParameter = context.prototype.getParameter;
+		context.prototype.getParameter = function (parameter) {
+			// UNMASKED_VENDOR_WEBGL and UNMASKED_RENDERER_WEBGL report SwiftShader in headless Chrome
+			if (parameter === 37445) {
+				return 'Google Inc. (Intel)';
+			}
+			if (parameter === 37446) {
+				return 'ANGLE (Intel, Intel(R) UHD Graphics 620 Direct3D11 vs_5_0 ps_5_0, D3D11)';
+			}
+			return getParameter.call(this, parameter);
+		};
+	}
+
+	if (window.outerWidth === 0 && window.outerHeight === 0) {
+		define(window, 'outerWidth', window.innerWidth);
+		define(window, 'outerHeight', window.innerHeight + 85);
+	}
+})();`
+
+// chromeVersionPattern extracts the version from a Chrome user agent
+var chromeVersionPattern = regexp.MustCompile(`Chrome/((\d+)[\d.]*)`)
+
+// stealth returns the action that makes a tab look like a regular Chrome: it overrides the user agent and client
+// hints with userAgentOverride and adds stealthScript to every document
+func stealth(opts Options) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		override, err := userAgentOverride(ctx, opts)
+		if err != nil {
+			return err
+		}
+		if err := override.Do(ctx); err != nil {
+			return err
+		}
+
+		if _, err := page.AddScriptToEvaluateOnNewDocument(stealthScript).Do(ctx); err != nil {
+			return fmt.Errorf("failed to add the stealth script: %v", err)
+		}
+		return nil
+	})
+}
+
+// userAgentOverride returns the user agent and client hints of the browser without "Headless" in them, in
+// opts.Language if set. With opts.Browser.Mobile they are the ones of Chrome on an Android phone instead of a desktop.
+func userAgentOverride(ctx context.Context, opts Options) (*emulation.SetUserAgentOverrideParams, error) {
+	_, _, _, userAgent, _, err := browser.GetVersion().Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	userAgent = strings.ReplaceAll(userAgent, "HeadlessChrome", "Chrome")
+
+	fullVersion, majorVersion := "129.0.0.0", "129"
+	if match := chromeVersionPattern.FindStringSubmatch(userAgent); match != nil {
+		fullVersion, majorVersion = match[1], match[2]
+	}
+	platform, hintPlatform := "Linux x86_64", "Linux"
+	switch {
+	case opts.Browser.Mobile:
+		userAgent = fmt.Sprintf("Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/%s Mobile Safari/537.36", fullVersion)
+		platform, hintPlatform = "Linux armv8l", "Android"
+	case strings.Contains(userAgent, "Windows"):
+		platform, hintPlatform = "Win32", "Windows"
+	case strings.Contains(userAgent, "Macintosh"):
+		platform, hintPlatform = "MacIntel", "macOS"
+	}
+	brands := func(version string) []*emulation.UserAgentBrandVersion {
+		return []*emulation.UserAgentBrandVersion{
+			{Brand: "Google Chrome", Version: version},
+			{Brand: "Chromium", Version: version},
+			{Brand: "Not=A?Brand", Version: "24"},
+		}
+	}
+	metadata := &emulation.UserAgentMetadata{
+		Brands:          brands(majorVersion),
+		FullVersionList: brands(fullVersion),
+		Platform:        hintPlatform,
+		Architecture:    "x86",
+		Bitness:         "64",
+		Mobile:          opts.Browser.Mobile,
+	}
+	if opts.Browser.Mobile {
+		metadata.Architecture, metadata.Bitness = "", ""
+	}
+
+	override := emulation.SetUserAgentOverride(userAgent).WithPlatform(platform).WithUserAgentMetadata(metadata)
+	if acceptLanguage := AcceptLanguage(opts); acceptLanguage != "" {
+		override = override.WithAcceptLanguage(acceptLanguage)
+	}
+	return override, nil
+}
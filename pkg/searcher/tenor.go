@@ -0,0 +1,120 @@
+package searcher
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+func init() {
+	Register(Tenor{})
+}
+
+// tenorPageSize is the largest page the Tenor API returns
+const tenorPageSize = 50
+
+// Tenor searches animated GIFs through the Tenor API, returning GIF or MP4 renditions depending on
+// Options.AnimationFormat. It needs an API key, set as the "tenor" credential or the TENOR_API_KEY environment variable.
+type Tenor struct{}
+
+// Name returns the engine name
+func (Tenor) Name() string {
+	return "tenor"
+}
+
+// SearchURL returns the search URL for the query continuing from pos, the position token of the previous page.
+// Content is limited to the medium filter unless mature is set.
+func (Tenor) SearchURL(query, key, pos string, mature bool) string {
+	filter := "medium"
+	if mature {
+		filter = "off"
+	}
+	params := url.Values{
+		"q":             {query},
+		"key":           {key},
+		"limit":         {strconv.Itoa(tenorPageSize)},
+		"media_filter":  {"gif,tinygif,mp4,tinymp4"},
+		"contentfilter": {filter},
+	}
+	if pos != "" {
+		params.Set("pos", pos)
+	}
+	return "https://tenor.googleapis.com/v2/search?" + params.Encode()
+}
+
+// tenorMedia is one of the formats Tenor encodes a GIF in
+type tenorMedia struct {
+	URL  string `json:"url"`
+	Dims []int  `json:"dims"`
+}
+
+// tenorResponse is the response of the search endpoint
+type tenorResponse struct {
+	Results []struct {
+		ContentDescription string                `json:"content_description"`
+		ItemURL            string                `json:"itemurl"`
+		MediaFormats       map[string]tenorMedia `json:"media_formats"`
+	} `json:"results"`
+	Next string `json:"next"`
+}
+
+// Search pages through the Tenor search results.
+// With Options.FullRes the full-size rendition is returned, otherwise the tiny one.
+func (t Tenor) Search(ctx context.Context, query string, opts Options) ([]Result, error) {
+	key := opts.credential("tenor", "TENOR_API_KEY")
+	if key == "" {
+		return nil, fmt.Errorf("tenor needs an API key: set TENOR_API_KEY or pass the tenor credential")
+	}
+	mp4, err := animationMP4(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	format, contentType := "gif", "image/gif"
+	if mp4 {
+		format, contentType = "mp4", "video/mp4"
+	}
+	if !opts.FullRes {
+		format = "tiny" + format
+	}
+
+	var results []Result
+	pos := ""
+	for page := 0; page < maxPages(opts); page++ {
+		if opts.Limit > 0 && len(results) >= opts.Limit {
+			break
+		}
+
+		var resp tenorResponse
+		if err := fetchJSON(ctx, opts, t.SearchURL(query, key, pos, opts.Mature), nil, &resp); err != nil {
+			return nil, fmt.Errorf("failed to fetch Tenor GIFs: %v", err)
+		}
+
+		for _, gif := range resp.Results {
+			media, ok := gif.MediaFormats[format]
+			if !ok || media.URL == "" {
+				continue
+			}
+			result := Result{
+				URL:         media.URL,
+				PageURL:     gif.ItemURL,
+				Engine:      t.Name(),
+				Query:       query,
+				Title:       gif.ContentDescription,
+				ContentType: contentType,
+			}
+			if len(media.Dims) == 2 {
+				result.Width, result.Height = media.Dims[0], media.Dims[1]
+			}
+			results = append(results, result)
+		}
+
+		if resp.Next == "" || len(resp.Results) == 0 {
+			break
+		}
+		pos = resp.Next
+	}
+
+	return LimitResults(results, opts.Limit), nil
+}
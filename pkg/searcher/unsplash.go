@@ -0,0 +1,110 @@
+package searcher
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+func init() {
+	Register(Unsplash{})
+}
+
+// unsplashPageSize is the largest page the Unsplash API returns
+const unsplashPageSize = 30
+
+// unsplashOrientations maps Options.Orientation values to the Unsplash orientation parameter
+var unsplashOrientations = map[string]string{
+	"landscape": "landscape",
+	"portrait":  "portrait",
+	"square":    "squarish",
+}
+
+// Unsplash searches photos through the Unsplash API.
+// It needs an access key, set as the "unsplash" credential or the UNSPLASH_ACCESS_KEY environment variable.
+type Unsplash struct{}
+
+// Name returns the engine name
+func (Unsplash) Name() string {
+	return "unsplash"
+}
+
+// SearchURL returns the search/photos URL for the query and 1-based page
+func (Unsplash) SearchURL(query string, page int, opts Options) string {
+	params := url.Values{
+		"query":    {query},
+		"page":     {strconv.Itoa(page)},
+		"per_page": {strconv.Itoa(unsplashPageSize)},
+	}
+	if orientation := unsplashOrientations[opts.Orientation]; orientation != "" {
+		params.Set("orientation", orientation)
+	}
+	return "https://api.unsplash.com/search/photos?" + params.Encode()
+}
+
+// unsplashResponse is the response of the search/photos endpoint
+type unsplashResponse struct {
+	TotalPages int `json:"total_pages"`
+	Results    []struct {
+		Width          int    `json:"width"`
+		Height         int    `json:"height"`
+		Description    string `json:"description"`
+		AltDescription string `json:"alt_description"`
+		URLs           struct {
+			Full string `json:"full"`
+		} `json:"urls"`
+		Links struct {
+			HTML string `json:"html"`
+		} `json:"links"`
+		User struct {
+			Name string `json:"name"`
+		} `json:"user"`
+	} `json:"results"`
+}
+
+// Search pages through the Unsplash photo search results
+func (u Unsplash) Search(ctx context.Context, query string, opts Options) ([]Result, error) {
+	key := opts.credential("unsplash", "UNSPLASH_ACCESS_KEY")
+	if key == "" {
+		return nil, fmt.Errorf("unsplash needs an access key: set UNSPLASH_ACCESS_KEY or pass the unsplash credential")
+	}
+	header := http.Header{"Authorization": {"Client-ID " + key}, "Accept-Version": {"v1"}}
+
+	var results []Result
+	for page := 1; page <= maxPages(opts); page++ {
+		if opts.Limit > 0 && len(results) >= opts.Limit {
+			break
+		}
+
+		var resp unsplashResponse
+		if err := fetchJSON(ctx, opts, u.SearchURL(query, page, opts), header, &resp); err != nil {
+			return nil, fmt.Errorf("failed to fetch Unsplash photos: %v", err)
+		}
+
+		for _, photo := range resp.Results {
+			title := photo.Description
+			if title == "" {
+				title = photo.AltDescription
+			}
+			results = append(results, Result{
+				URL:     photo.URLs.Full,
+				PageURL: photo.Links.HTML,
+				Engine:  u.Name(),
+				Query:   query,
+				Title:   title,
+				Width:   photo.Width,
+				Height:  photo.Height,
+				License: "Unsplash License",
+				Author:  photo.User.Name,
+			})
+		}
+
+		if page >= resp.TotalPages {
+			break
+		}
+	}
+
+	return LimitResults(results, opts.Limit), nil
+}
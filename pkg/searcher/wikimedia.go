@@ -0,0 +1,151 @@
+package searcher
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	Register(Wikimedia{})
+}
+
+// wikimediaPageSize is how many files are requested per page
+const wikimediaPageSize = 50
+
+// htmlTagPattern matches HTML tags, which Commons includes in some metadata values
+var htmlTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// Wikimedia searches Wikimedia Commons files through the MediaWiki API and returns the original files
+type Wikimedia struct{}
+
+// Name returns the engine name
+func (Wikimedia) Name() string {
+	return "wikimedia"
+}
+
+// SearchURL returns the MediaWiki API URL searching the File namespace for the query from the given offset
+func (Wikimedia) SearchURL(query string, offset int) string {
+	return "https://commons.wikimedia.org/w/api.php?" + url.Values{
+		"action":              {"query"},
+		"format":              {"json"},
+		"generator":           {"search"},
+		"gsrsearch":           {query + " filetype:bitmap"},
+		"gsrnamespace":        {"6"},
+		"gsrlimit":            {strconv.Itoa(wikimediaPageSize)},
+		"gsroffset":           {strconv.Itoa(offset)},
+		"prop":                {"imageinfo"},
+		"iiprop":              {"url|size|mime|extmetadata"},
+		"iiextmetadatafilter": {"LicenseShortName|LicenseUrl|Artist|ObjectName"},
+	}.Encode()
+}
+
+// wikimediaValue is an extmetadata entry
+type wikimediaValue struct {
+	Value string `json:"value"`
+}
+
+// wikimediaResponse is the response of the query action with the search generator
+type wikimediaResponse struct {
+	Continue *struct {
+		Offset int `json:"gsroffset"`
+	} `json:"continue"`
+	Query struct {
+		Pages map[string]struct {
+			Index     int    `json:"index"`
+			Title     string `json:"title"`
+			ImageInfo []struct {
+				URL            string `json:"url"`
+				DescriptionURL string `json:"descriptionurl"`
+				Width          int    `json:"width"`
+				Height         int    `json:"height"`
+				Mime           string `json:"mime"`
+				ExtMetadata    struct {
+					LicenseShortName wikimediaValue `json:"LicenseShortName"`
+					LicenseURL       wikimediaValue `json:"LicenseUrl"`
+					Artist           wikimediaValue `json:"Artist"`
+					ObjectName       wikimediaValue `json:"ObjectName"`
+				} `json:"extmetadata"`
+			} `json:"imageinfo"`
+		} `json:"pages"`
+	} `json:"query"`
+}
+
+// Search pages through the Commons file search results
+func (w Wikimedia) Search(ctx context.Context, query string, opts Options) ([]Result, error) {
+	// Wikimedia asks API clients to identify themselves instead of posing as a browser
+	header := http.Header{"User-Agent": {"image-searcher (https://github.com/selman92/image-searcher)"}}
+
+	var results []Result
+	offset := 0
+	for page := 0; page < maxPages(opts); page++ {
+		if opts.Limit > 0 && len(results) >= opts.Limit {
+			break
+		}
+
+		var resp wikimediaResponse
+		if err := fetchJSON(ctx, opts, w.SearchURL(query, offset), header, &resp); err != nil {
+			return nil, fmt.Errorf("failed to fetch Wikimedia Commons files: %v", err)
+		}
+
+		// Pages are keyed by page ID, so restore the search ranking from their index
+		var pageResults []Result
+		var indexes []int
+		for _, p := range resp.Query.Pages {
+			if len(p.ImageInfo) == 0 {
+				continue
+			}
+			info := p.ImageInfo[0]
+			title := stripHTML(info.ExtMetadata.ObjectName.Value)
+			if title == "" {
+				title = strings.TrimPrefix(p.Title, "File:")
+			}
+			pageResults = append(pageResults, Result{
+				URL:         info.URL,
+				PageURL:     info.DescriptionURL,
+				Engine:      w.Name(),
+				Query:       query,
+				Title:       title,
+				Width:       info.Width,
+				Height:      info.Height,
+				ContentType: info.Mime,
+				License:     info.ExtMetadata.LicenseShortName.Value,
+				LicenseURL:  info.ExtMetadata.LicenseURL.Value,
+				Author:      stripHTML(info.ExtMetadata.Artist.Value),
+			})
+			indexes = append(indexes, p.Index)
+		}
+		sort.Sort(byIndex{pageResults, indexes})
+		results = append(results, pageResults...)
+
+		if resp.Continue == nil {
+			break
+		}
+		offset = resp.Continue.Offset
+	}
+
+	return LimitResults(results, opts.Limit), nil
+}
+
+// byIndex sorts results by a parallel slice of ranking indexes
+type byIndex struct {
+	results []Result
+	indexes []int
+}
+
+func (b byIndex) Len() int           { return len(b.results) }
+func (b byIndex) Less(i, j int) bool { return b.indexes[i] < b.indexes[j] }
+func (b byIndex) Swap(i, j int) {
+	b.results[i], b.results[j] = b.results[j], b.results[i]
+	b.indexes[i], b.indexes[j] = b.indexes[j], b.indexes[i]
+}
+
+// stripHTML removes tags from a metadata value and trims the remaining text
+func stripHTML(value string) string {
+	return strings.TrimSpace(htmlTagPattern.ReplaceAllString(value, ""))
+}
@@ -0,0 +1,106 @@
+package searcher
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+func init() {
+	Register(Yahoo{})
+}
+
+// yahooScroll counts the result items on the page and clicks "Show more images"
+var yahooScroll = scrollStrategy{
+	countJS: `document.querySelectorAll('#sres li[data]').length`,
+	moreJS:  `(() => { const b = document.querySelector('button[name="more-res"], .more-res'); if (b && b.offsetParent !== null) { b.click(); return true; } return false; })()`,
+}
+
+// yahooExtractJS reads the JSON metadata Yahoo stores in the data attribute of each result item,
+// falling back to the imgurl and rurl parameters of the result link
+const yahooExtractJS = `Array.from(document.querySelectorAll('#sres li')).map(li => {
+	try {
+		const d = JSON.parse(li.getAttribute('data'));
+		if (d && d.iurl) return {url: d.iurl, page: d.rurl || '', title: d.alt || '', w: Number(d.w) || 0, h: Number(d.h) || 0};
+	} catch (e) {}
+	const a = li.querySelector('a[href*="imgurl="]');
+	if (!a) return null;
+	const params = new URL(a.href).searchParams;
+	return {url: params.get('imgurl') || '', page: params.get('rurl') || '', title: '', w: 0, h: 0};
+}).filter(r => r && r.url)`
+
+// yahooItem is a result extracted by yahooExtractJS
+type yahooItem struct {
+	URL    string `json:"url"`
+	Page   string `json:"page"`
+	Title  string `json:"title"`
+	Width  int    `json:"w"`
+	Height int    `json:"h"`
+}
+
+// Yahoo searches Yahoo Images with a headless browser
+type Yahoo struct{}
+
+// Name returns the engine name
+func (Yahoo) Name() string {
+	return "yahoo"
+}
+
+// UsesBrowser reports that the engine drives a browser
+func (Yahoo) UsesBrowser() bool {
+	return true
+}
+
+// SearchURL returns the Yahoo image search page URL for the query
+func (Yahoo) SearchURL(query string) string {
+	return "https://images.search.yahoo.com/search/images?" + url.Values{"p": {query}}.Encode()
+}
+
+// Search searches for images on Yahoo using chromedp and returns the original image URLs
+func (y Yahoo) Search(ctx context.Context, query string, opts Options) ([]Result, error) {
+	var items []yahooItem
+	searchURL := y.SearchURL(query)
+
+	tab, err := openTab(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer tab.Close()
+
+	// Load the Yahoo image search page, scroll down to load more images (simulate user interaction) and extract
+	// the result metadata
+	err = loadResultsPage(ctx, tab, y.Name(), searchURL, opts)
+	if err == nil {
+		err = scrollPage(ctx, tab, yahooScroll, 5, opts)
+	}
+	if err == nil {
+		err = tab.Evaluate(yahooExtractJS, &items)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch Yahoo images: %w", err)
+	}
+
+	if captured := capturedResults(tab, y.Name(), query, searchURL); captured != nil {
+		return LimitResults(captured, opts.Limit), nil
+	}
+
+	var results []Result
+	for _, item := range items {
+		// Yahoo often leaves the scheme off the image URL
+		imageURL := item.URL
+		if u, err := url.Parse(imageURL); err == nil && u.Scheme == "" {
+			imageURL = "https://" + imageURL
+		}
+		results = append(results, Result{
+			URL:     imageURL,
+			PageURL: item.Page,
+			Engine:  y.Name(),
+			Query:   query,
+			Title:   item.Title,
+			Width:   item.Width,
+			Height:  item.Height,
+		})
+	}
+
+	return LimitResults(results, opts.Limit), nil
+}
@@ -0,0 +1,246 @@
+package searcher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func init() {
+	Register(Yandex{})
+}
+
+// Yandex searches Yandex Images with a headless browser
+type Yandex struct{}
+
+// yandexScroll counts the result covers on the page and clicks "Show more"
+var yandexScroll = scrollStrategy{
+	countJS: `document.querySelectorAll('a.Link.ContentImage-Cover').length`,
+	moreJS:  `(() => { const b = document.querySelector('.FetchListButton-Button, .more__button'); if (b && b.offsetParent !== null) { b.click(); return true; } return false; })()`,
+}
+
+// Name returns the engine name
+func (Yandex) Name() string {
+	return "yandex"
+}
+
+// UsesBrowser reports that the engine drives a browser
+func (Yandex) UsesBrowser() bool {
+	return true
+}
+
+// SearchURL returns the Yandex image search page URL for the query.
+// opts.Yandex.Region, or else the country of opts.Region, selects the regional index through the lr parameter,
+// opts.Language the interface language,
+// and the filters in opts are added as their Yandex parameters.
+func (Yandex) SearchURL(query string, opts Options) string {
+	searchURL := fmt.Sprintf("https://yandex.com/images/search?text=%s", url.QueryEscape(filteredQuery(query, opts)))
+	region := opts.Yandex.Region
+	if region == "" {
+		region = yandexCountryRegions[strings.ToLower(opts.Region)]
+	}
+	if region != "" {
+		searchURL += "&lr=" + url.QueryEscape(region)
+	}
+	if opts.Language != "" {
+		searchURL += "&lang=" + url.QueryEscape(opts.Language)
+	}
+	params := url.Values{}
+	yandexFilters(params, opts)
+	if len(params) > 0 {
+		searchURL += "&" + params.Encode()
+	}
+	return searchURL
+}
+
+// Search searches for images on Yandex using chromedp and returns the image URLs, or with opts.NoBrowser
+// through SearchWithoutBrowser
+func (y Yandex) Search(ctx context.Context, query string, opts Options) ([]Result, error) {
+	if opts.NoBrowser {
+		return y.SearchWithoutBrowser(ctx, query, opts)
+	}
+	var links []string
+	searchURL := y.SearchURL(query, opts)
+
+	tab, err := openTab(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer tab.Close()
+
+	// Load the Yandex image search page and extract image URLs from <a> tags
+	err = loadResultsPage(ctx, tab, y.Name(), searchURL, opts)
+	var challengeErr *ChallengeError
+	if errors.As(err, &challengeErr) {
+		return nil, err
+	}
+	logError(err)
+
+	err = scrollPage(ctx, tab, yandexScroll, 5, opts)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, ctxErr
+	}
+	logError(err)
+
+	if captured := capturedResults(tab, y.Name(), query, searchURL); captured != nil {
+		return LimitResults(captured, opts.Limit), nil
+	}
+
+	err = tab.Evaluate(`Array.from(document.querySelectorAll('a.Link.ContentImage-Cover')).map(a => a.href)`, &links)
+
+	if err != nil {
+		logError(err)
+		return nil, fmt.Errorf("failed to fetch Yandex image links: %v", err)
+	}
+
+	// Parse img_url parameter from the href attribute to get the actual image URLs
+	imageURLs := parseYandexImageURLs(links)
+
+	return LimitResults(newResults(imageURLs, y.Name(), query, searchURL), opts.Limit), nil
+}
+
+func logError(err error) {
+	if err != nil {
+		slog.Warn("Yandex browser step failed", "error", err)
+	}
+}
+
+// Parse img_url parameter from the Yandex href to extract the actual image URLs
+func parseYandexImageURLs(links []string) []string {
+	var imageURLs []string
+	for _, link := range links {
+		// Parse the href to extract the img_url query parameter
+		u, err := url.Parse(link)
+		if err != nil {
+			continue
+		}
+		// Extract img_url parameter from the href
+		imgURL := u.Query().Get("img_url")
+		if imgURL != "" {
+			imageURLs = append(imageURLs, imgURL)
+		}
+	}
+	return imageURLs
+}
+
+// yandexURLKeys are the keys of the original image URL in the result data Yandex embeds in its page, img_href in the
+// data-bem attribute of the .serp-item results and origUrl in the data-state of the newer layout
+var yandexURLKeys = map[string]bool{"img_href": true, "origUrl": true}
+
+// SearchWithoutBrowser searches Yandex Images through the plain HTML of its results pages, which embed the original
+// image URLs in the result data and the links of the result covers. Yandex answers many plain requests with a
+// CAPTCHA page, which fails the search with a *ChallengeError.
+func (y Yandex) SearchWithoutBrowser(ctx context.Context, query string, opts Options) ([]Result, error) {
+	searchURL := y.SearchURL(query, opts)
+	header := http.Header{}
+	if acceptLanguage := AcceptLanguage(opts); acceptLanguage != "" {
+		header.Set("Accept-Language", acceptLanguage)
+	}
+
+	var imageURLs []string
+	seen := make(map[string]bool)
+	for page := 0; page < maxPages(opts); page++ {
+		pageURL := searchURL
+		if page > 0 {
+			pageURL += fmt.Sprintf("&p=%d", page)
+		}
+		body, err := fetch(ctx, opts, pageURL, header)
+		if err != nil {
+			if page > 0 {
+				// Keep the results of the pages fetched so far
+				slog.Warn("Failed to fetch more Yandex results", "page", page, "error", err)
+				break
+			}
+			return nil, fmt.Errorf("failed to fetch Yandex images: %w", err)
+		}
+		if strings.Contains(string(body), "showcaptcha") || strings.Contains(string(body), "CheckboxCaptcha") {
+			if page > 0 {
+				slog.Warn("Yandex showed a CAPTCHA page, keeping the results found so far", "page", page)
+				break
+			}
+			return nil, &ChallengeError{Engine: y.Name(), Challenge: "Yandex CAPTCHA", URL: pageURL}
+		}
+
+		added := 0
+		for _, imageURL := range parseYandexPage(string(body)) {
+			if !seen[imageURL] {
+				seen[imageURL] = true
+				imageURLs = append(imageURLs, imageURL)
+				added++
+			}
+		}
+		slog.Debug("Fetched Yandex results page", "page", page, "results", added)
+		if added == 0 || (opts.Limit > 0 && len(imageURLs) >= opts.Limit) {
+			break
+		}
+	}
+
+	return LimitResults(newResults(imageURLs, y.Name(), query, searchURL), opts.Limit), nil
+}
+
+// parseYandexPage extracts the original image URLs from the HTML of a Yandex results page: from the result data
+// embedded as JSON in the data-bem and data-state attributes, and from the img_url of the result cover links
+func parseYandexPage(page string) []string {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(page))
+	if err != nil {
+		return nil
+	}
+
+	var imageURLs []string
+	seen := make(map[string]bool)
+	doc.Find(".serp-item[data-bem], [data-state]").Each(func(_ int, item *goquery.Selection) {
+		attr := "data-state"
+		if item.Is(".serp-item[data-bem]") {
+			attr = "data-bem"
+		}
+		var data any
+		if err := json.Unmarshal([]byte(item.AttrOr(attr, "")), &data); err != nil {
+			return
+		}
+		for _, imageURL := range yandexEmbeddedURLs(data) {
+			if strings.HasPrefix(imageURL, "http") && !seen[imageURL] {
+				seen[imageURL] = true
+				imageURLs = append(imageURLs, imageURL)
+			}
+		}
+	})
+
+	var links []string
+	doc.Find(`a[href*="img_url="]`).Each(func(_ int, link *goquery.Selection) {
+		links = append(links, link.AttrOr("href", ""))
+	})
+	return append(imageURLs, parseYandexImageURLs(links)...)
+}
+
+// yandexEmbeddedURLs returns the string values of the yandexURLKeys anywhere in decoded JSON
+func yandexEmbeddedURLs(data any) []string {
+	var imageURLs []string
+	switch value := data.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if imageURL, ok := value[key].(string); ok && yandexURLKeys[key] {
+				imageURLs = append(imageURLs, imageURL)
+				continue
+			}
+			imageURLs = append(imageURLs, yandexEmbeddedURLs(value[key])...)
+		}
+	case []any:
+		for _, item := range value {
+			imageURLs = append(imageURLs, yandexEmbeddedURLs(item)...)
+		}
+	}
+	return imageURLs
+}
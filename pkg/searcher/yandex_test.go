@@ -0,0 +1,19 @@
+package searcher
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseYandexPage(t *testing.T) {
+	page := `<div class="serp-list">
+<div class="serp-item" data-bem='{"serp-item":{"img_href":"https://example.com/cat.jpg","preview":[{"url":"https://example.com/cat.jpg"}]}}'></div>
+<div class="serp-item" data-bem="{&quot;serp-item&quot;:{&quot;img_href&quot;:&quot;https://example.com/dog.jpg&quot;}}"></div>
+<div data-state='{"initialState":{"serpList":{"items":{"entities":{"a":{"origUrl":"https://example.com/bird.png"},"b":{"origUrl":"https://example.com/cat.jpg"}}}}}}'></div>
+<a class="Link ContentImage-Cover" href="/images/search?pos=0&amp;img_url=https%3A%2F%2Fexample.com%2Ffish.webp&amp;text=cats"></a>
+</div>`
+	want := []string{"https://example.com/cat.jpg", "https://example.com/dog.jpg", "https://example.com/bird.png", "https://example.com/fish.webp"}
+	if got := parseYandexPage(page); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
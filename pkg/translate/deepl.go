@@ -0,0 +1,60 @@
+package translate
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+func init() {
+	Register(DeepL{})
+}
+
+// DeepL translates with the DeepL API.
+// It needs an authentication key, set as the "deepl" credential or the DEEPL_API_KEY environment variable.
+// Keys of the free plan, which end in ":fx", are sent to the free API endpoint.
+type DeepL struct{}
+
+// Name returns the backend name
+func (DeepL) Name() string {
+	return "deepl"
+}
+
+// Endpoint returns the translate endpoint for the key's plan
+func (DeepL) Endpoint(key string) string {
+	if strings.HasSuffix(key, ":fx") {
+		return "https://api-free.deepl.com/v2/translate"
+	}
+	return "https://api.deepl.com/v2/translate"
+}
+
+// deepLResponse is the response of the translate endpoint
+type deepLResponse struct {
+	Translations []struct {
+		Text string `json:"text"`
+	} `json:"translations"`
+}
+
+// Translate translates the text with the translate endpoint, detecting the source language
+func (d DeepL) Translate(ctx context.Context, text, target string, opts Options) (string, error) {
+	key := opts.credential("deepl", "DEEPL_API_KEY")
+	if key == "" {
+		return "", fmt.Errorf("deepl needs an API key: set DEEPL_API_KEY or pass the deepl credential")
+	}
+	form := url.Values{
+		"text":        {text},
+		"target_lang": {strings.ToUpper(target)},
+	}
+	header := http.Header{"Authorization": {"DeepL-Auth-Key " + key}}
+
+	var resp deepLResponse
+	if err := postFormJSON(ctx, d.Endpoint(key), form, header, &resp); err != nil {
+		return "", fmt.Errorf("failed to translate with DeepL: %v", err)
+	}
+	if len(resp.Translations) == 0 {
+		return "", fmt.Errorf("DeepL returned no translation")
+	}
+	return resp.Translations[0].Text, nil
+}
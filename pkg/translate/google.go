@@ -0,0 +1,54 @@
+package translate
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"net/url"
+)
+
+func init() {
+	Register(Google{})
+}
+
+// Google translates with the Google Cloud Translation API (v2).
+// It needs an API key, set as the "google-translate" credential or the GOOGLE_TRANSLATE_API_KEY environment variable.
+type Google struct{}
+
+// Name returns the backend name
+func (Google) Name() string {
+	return "google"
+}
+
+// googleResponse is the response of the translate endpoint
+type googleResponse struct {
+	Data struct {
+		Translations []struct {
+			TranslatedText string `json:"translatedText"`
+		} `json:"translations"`
+	} `json:"data"`
+}
+
+// Translate translates the text with the translate endpoint, detecting the source language
+func (g Google) Translate(ctx context.Context, text, target string, opts Options) (string, error) {
+	key := opts.credential("google-translate", "GOOGLE_TRANSLATE_API_KEY")
+	if key == "" {
+		return "", fmt.Errorf("google needs an API key: set GOOGLE_TRANSLATE_API_KEY or pass the google-translate credential")
+	}
+	form := url.Values{
+		"key":    {key},
+		"q":      {text},
+		"target": {target},
+		"format": {"text"},
+	}
+
+	var resp googleResponse
+	if err := postFormJSON(ctx, "https://translation.googleapis.com/language/translate/v2", form, nil, &resp); err != nil {
+		return "", fmt.Errorf("failed to translate with Google: %v", err)
+	}
+	if len(resp.Data.Translations) == 0 {
+		return "", fmt.Errorf("Google returned no translation")
+	}
+	// Entities are escaped even in text format
+	return html.UnescapeString(resp.Data.Translations[0].TranslatedText), nil
+}
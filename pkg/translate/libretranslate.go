@@ -0,0 +1,53 @@
+package translate
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+func init() {
+	Register(LibreTranslate{})
+}
+
+// libreTranslateURL is the public LibreTranslate instance, which needs an API key
+const libreTranslateURL = "https://libretranslate.com"
+
+// LibreTranslate translates with a LibreTranslate server, the public instance unless opts.URL points to a
+// self-hosted one. An API key, if the server needs one, is set as the "libretranslate" credential or the
+// LIBRETRANSLATE_API_KEY environment variable.
+type LibreTranslate struct{}
+
+// Name returns the backend name
+func (LibreTranslate) Name() string {
+	return "libretranslate"
+}
+
+// libreTranslateResponse is the response of the translate endpoint
+type libreTranslateResponse struct {
+	TranslatedText string `json:"translatedText"`
+}
+
+// Translate translates the text with the translate endpoint, detecting the source language
+func (l LibreTranslate) Translate(ctx context.Context, text, target string, opts Options) (string, error) {
+	endpoint := opts.URL
+	if endpoint == "" {
+		endpoint = libreTranslateURL
+	}
+	form := url.Values{
+		"q":      {text},
+		"source": {"auto"},
+		"target": {target},
+		"format": {"text"},
+	}
+	if key := opts.credential("libretranslate", "LIBRETRANSLATE_API_KEY"); key != "" {
+		form.Set("api_key", key)
+	}
+
+	var resp libreTranslateResponse
+	if err := postFormJSON(ctx, strings.TrimSuffix(endpoint, "/")+"/translate", form, nil, &resp); err != nil {
+		return "", fmt.Errorf("failed to translate with LibreTranslate: %v", err)
+	}
+	return resp.TranslatedText, nil
+}
@@ -0,0 +1,108 @@
+// Package translate translates search queries into other languages.
+//
+// Every backend implements Translator and is registered by name, so callers can look backends up
+// from user input and third parties can plug in their own with Register.
+package translate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Options holds the settings shared by every backend
+type Options struct {
+	URL string // Endpoint of self-hosted backends such as LibreTranslate, empty for the backend's default
+
+	// Credentials holds API keys for the backends, keyed by credential name (e.g. "deepl").
+	// Backends fall back to an environment variable when a credential is not set here.
+	Credentials map[string]string
+}
+
+// credential returns the named credential from the options or, if unset, from the environment variable
+func (o Options) credential(name, env string) string {
+	if value := o.Credentials[name]; value != "" {
+		return value
+	}
+	return os.Getenv(env)
+}
+
+// Translator is implemented by every translation backend
+type Translator interface {
+	// Name returns the unique name used to select the backend, e.g. "deepl"
+	Name() string
+	// Translate returns the text translated into the target language, given as a code like "de" or "ja".
+	// The source language is detected by the backend.
+	Translate(ctx context.Context, text, target string, opts Options) (string, error)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Translator)
+	names      []string
+)
+
+// Register makes a backend available by its name. It panics if a backend with the same name is already registered.
+func Register(translator Translator) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	name := translator.Name()
+	if _, exists := registry[name]; exists {
+		panic(fmt.Sprintf("translate: backend %q registered twice", name))
+	}
+	registry[name] = translator
+	names = append(names, name)
+}
+
+// Lookup returns the backend registered with the given name
+func Lookup(name string) (Translator, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	translator, ok := registry[name]
+	return translator, ok
+}
+
+// Names returns the names of all registered backends in registration order
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	return append([]string(nil), names...)
+}
+
+// httpClient is used for every request to a translation backend
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// postFormJSON posts the form values with the given extra headers and decodes the JSON response into v
+func postFormJSON(ctx context.Context, endpoint string, form url.Values, header http.Header, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s from %s", resp.Status, req.URL.Host)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %v", err)
+	}
+	return nil
+}
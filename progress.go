@@ -0,0 +1,164 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/schollz/progressbar/v3"
+	"github.com/selman92/image-searcher/pkg/searcher"
+)
+
+// progress shows what a run is doing on standard error. On a terminal every engine gets a status line that
+// is redrawn as its search finishes, and every stage a progress bar. Otherwise, or with -no-progress, it
+// prints plain lines suited to CI logs.
+type progress struct {
+	w     io.Writer
+	live  bool // Draws status lines and bars instead of plain lines
+	width int  // Width of the engine name column
+
+	mu      sync.Mutex
+	engines []string
+	status  map[string]string
+	drawn   int // Number of status lines on screen, to move the cursor back over them
+}
+
+// newProgress returns the progress display of the run, drawing bars unless disabled or stderr isn't a terminal
+func newProgress(disabled bool) *progress {
+	live := false
+	if info, err := os.Stderr.Stat(); err == nil && !disabled {
+		live = info.Mode()&os.ModeCharDevice != 0
+	}
+	return &progress{w: os.Stderr, live: live, status: make(map[string]string)}
+}
+
+// searching shows that the engines' searches have started. The lines of searches still running stay on screen,
+// since several queries may be searched at once.
+func (p *progress) searching(engines []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var running []string
+	for _, engine := range p.engines {
+		if p.status[engine] == "searching..." {
+			running = append(running, engine)
+		}
+	}
+	p.engines = append(running, engines...)
+	for _, engine := range engines {
+		p.width = max(p.width, len(engine))
+		p.status[engine] = "searching..."
+		if !p.live {
+			fmt.Fprintf(p.w, "Searching on %s...\n", engine)
+		}
+	}
+	p.redraw()
+}
+
+// searched shows the outcome of an engine's search
+func (p *progress) searched(engine string, found int, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var challengeErr *searcher.ChallengeError
+	switch {
+	case errors.As(err, &challengeErr):
+		p.status[engine] = fmt.Sprintf("blocked by a %s page", challengeErr.Challenge)
+	case err != nil:
+		p.status[engine] = "search failed, see the log"
+	case found == 0:
+		p.status[engine] = "no images found"
+	default:
+		p.status[engine] = fmt.Sprintf("%d images found", found)
+	}
+	if !p.live {
+		fmt.Fprintf(p.w, "%s: %s\n", engine, p.status[engine])
+	}
+	p.redraw()
+}
+
+// redraw rewrites the engine status lines in place. The caller must hold p.mu.
+func (p *progress) redraw() {
+	if !p.live {
+		return
+	}
+	if p.drawn > 0 {
+		fmt.Fprintf(p.w, "\033[%dA", p.drawn)
+	}
+	for _, engine := range p.engines {
+		fmt.Fprintf(p.w, "\r\033[K%-*s  %s\n", p.width, engine, p.status[engine])
+	}
+	if p.drawn > len(p.engines) {
+		// Clear the lines left over from a longer list
+		fmt.Fprint(p.w, "\033[J")
+	}
+	p.drawn = len(p.engines)
+}
+
+// stage returns the progress of a stage processing total items. Items counted as missed, e.g. failed
+// downloads, are shown with the label.
+func (p *progress) stage(description, missedLabel string, total int) *stageProgress {
+	s := &stageProgress{w: p.w, description: description, missedLabel: missedLabel, total: total, start: time.Now()}
+	if p.live && total > 0 {
+		s.bar = progressbar.NewOptions(total,
+			progressbar.OptionSetDescription(description),
+			progressbar.OptionSetWriter(p.w),
+			progressbar.OptionEnableColorCodes(true),
+			progressbar.OptionShowCount(),
+			progressbar.OptionSetPredictTime(true),
+		)
+	}
+	return s
+}
+
+// stageProgress is the progress bar of a stage, or its plain lines printed every tenth of the way
+type stageProgress struct {
+	w           io.Writer
+	bar         *progressbar.ProgressBar
+	description string
+	missedLabel string
+	total       int
+	start       time.Time
+
+	done   atomic.Int64
+	missed atomic.Int64
+	bytes  atomic.Int64
+
+	mu      sync.Mutex
+	printed int64 // Last tenth printed in plain mode
+}
+
+// add counts a finished item and the bytes it saved
+func (s *stageProgress) add(missed bool, bytes int64) {
+	done := s.done.Add(1)
+	if missed {
+		s.missed.Add(1)
+	}
+	total := s.bytes.Add(bytes)
+	status := fmt.Sprintf("%d %s", s.missed.Load(), s.missedLabel)
+	if total > 0 {
+		status += fmt.Sprintf(", %s/s", formatSize(float64(total)/time.Since(s.start).Seconds()))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.bar != nil {
+		s.bar.Describe(fmt.Sprintf("%s (%s)", s.description, status))
+		s.bar.Add(1)
+		return
+	}
+	if tenth := done * 10 / int64(max(s.total, 1)); tenth > s.printed {
+		s.printed = tenth
+		fmt.Fprintf(s.w, "%s: %d/%d (%s)\n", s.description, done, s.total, status)
+	}
+}
+
+// finish ends the bar's line
+func (s *stageProgress) finish() {
+	if s.bar != nil {
+		s.bar.Finish()
+		fmt.Fprintln(s.w)
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// torAddress is the SOCKS port of a local Tor daemon
+const torAddress = "127.0.0.1:9050"
+
+// proxyPool rotates requests across the proxies of -proxy-list, round-robin or at random.
+// Proxies that fail maxFailures times in a row are skipped for the rest of the run while any other proxy is healthy.
+type proxyPool struct {
+	proxies     []*url.URL
+	random      bool
+	maxFailures int
+
+	// isolate gives every key passed to pick its own SOCKS credentials. Tor builds a separate circuit for
+	// every set of credentials, so each engine's API requests and downloads leave through a different exit.
+	// Chrome drops the credentials, so the browser-based engines share one circuit.
+	isolate bool
+
+	mu       sync.Mutex
+	next     int
+	failures map[string]int // Consecutive failures by proxy URL
+}
+
+// newProxyPool returns a pool over the proxies. rotation is "round-robin" or "random".
+func newProxyPool(proxies []*url.URL, rotation string, maxFailures int) (*proxyPool, error) {
+	if len(proxies) == 0 {
+		return nil, fmt.Errorf("the proxy list is empty")
+	}
+	if rotation != "round-robin" && rotation != "random" {
+		return nil, fmt.Errorf("invalid -proxy-rotation %q, expected round-robin or random", rotation)
+	}
+	return &proxyPool{proxies: proxies, random: rotation == "random", maxFailures: max(maxFailures, 1), failures: make(map[string]int)}, nil
+}
+
+// newTorPool returns a pool sending everything through the local Tor daemon, isolating the HTTP requests of each engine
+func newTorPool() *proxyPool {
+	tor := &url.URL{Scheme: "socks5", Host: torAddress}
+	return &proxyPool{proxies: []*url.URL{tor}, maxFailures: 1, isolate: true, failures: make(map[string]int)}
+}
+
+// loadProxyList reads one proxy URL per line, ignoring blank lines and # comments
+func loadProxyList(path string) ([]*url.URL, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read proxy list: %v", err)
+	}
+	defer f.Close()
+
+	var proxies []*url.URL
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		proxyURL, err := parseProxy(line)
+		if err != nil {
+			return nil, err
+		}
+		proxies = append(proxies, proxyURL)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read proxy list: %v", err)
+	}
+	return proxies, nil
+}
+
+// pick returns the proxy for the next request on behalf of key, the engine name
+func (p *proxyPool) pick(key string) *url.URL {
+	if p.isolate {
+		proxy := *p.proxies[0]
+		proxy.User = url.UserPassword(key, "image-searcher")
+		return &proxy
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	healthy := make([]*url.URL, 0, len(p.proxies))
+	for _, proxy := range p.proxies {
+		if p.failures[proxy.String()] < p.maxFailures {
+			healthy = append(healthy, proxy)
+		}
+	}
+	if len(healthy) == 0 {
+		// Every proxy is blacklisted, so keep rotating through all of them rather than stopping the run
+		healthy = p.proxies
+	}
+
+	if p.random {
+		return healthy[rand.IntN(len(healthy))]
+	}
+	proxy := healthy[p.next%len(healthy)]
+	p.next++
+	return proxy
+}
+
+// report records whether a request through the proxy succeeded
+func (p *proxyPool) report(proxy *url.URL, ok bool) {
+	if proxy == nil || p.isolate {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key := proxy.String()
+	if ok {
+		delete(p.failures, key)
+		return
+	}
+	p.failures[key]++
+	if p.failures[key] == p.maxFailures {
+		fmt.Fprintf(os.Stderr, "Blacklisting proxy %s after %d failures\n", proxy.Redacted(), p.maxFailures)
+	}
+}
+
+// proxyKey is the context key of the proxy chosen for a download
+type proxyKey struct{}
+
+// withProxy returns a context whose requests go through the proxy when sent with a pooled transport
+func withProxy(ctx context.Context, proxy *url.URL) context.Context {
+	return context.WithValue(ctx, proxyKey{}, proxy)
+}
+
+// contextProxy is the transport Proxy function of a client using a pool, returning the proxy stored in
+// the request's context by withProxy
+func contextProxy(req *http.Request) (*url.URL, error) {
+	proxy, _ := req.Context().Value(proxyKey{}).(*url.URL)
+	return proxy, nil
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/selman92/image-searcher/pkg/searcher"
+	"github.com/selman92/image-searcher/pkg/translate"
+)
+
+// translateQuery returns the query followed by its translations into the comma-separated languages.
+// Languages that fail to translate, or whose translation is not a valid query, are logged and left out,
+// and translations identical to a query already in the list are dropped.
+func translateQuery(ctx context.Context, query, languages string, translator translate.Translator, opts translate.Options) []string {
+	queries := []string{query}
+	for _, language := range strings.Split(languages, ",") {
+		language = strings.TrimSpace(language)
+		if language == "" {
+			continue
+		}
+		translated, err := translator.Translate(ctx, query, language, opts)
+		if err == nil {
+			translated, err = searcher.ParseQuery(translated)
+		}
+		if err != nil {
+			slog.Warn("Failed to translate query", "query", query, "language", language, "error", err)
+			continue
+		}
+		slog.Info("Translated query", "query", query, "language", language, "translation", translated)
+		if !containsFold(queries, translated) {
+			queries = append(queries, translated)
+		}
+	}
+	return queries
+}
+
+// containsFold reports whether the list contains the string, ignoring case
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// searchQueries runs the search of a single target for every query in turn, restricted to site if set, and merges
+// the results, dropping images an earlier query already returned. Results keep the query that found them, without
+// the site: operator so files are named after the query as typed. An error is only returned if every query failed.
+func searchQueries(ctx context.Context, target string, queries []string, site string, opts searcher.Options, timeout time.Duration, browsers chan struct{}, proxies *proxyPool) ([]searcher.Result, error) {
+	var results []searcher.Result
+	var errs []error
+	seen := make(map[string]bool)
+	for _, query := range queries {
+		found, err := searchTarget(ctx, target, siteQuery(query, site), opts, timeout, browsers, proxies)
+		if err != nil {
+			if len(queries) > 1 {
+				err = fmt.Errorf("%s: %w", query, err)
+			}
+			errs = append(errs, err)
+			continue
+		}
+		for _, result := range found {
+			if !seen[result.URL] {
+				seen[result.URL] = true
+				result.Query = query
+				results = append(results, result)
+			}
+		}
+	}
+	if len(errs) == len(queries) {
+		return nil, errors.Join(errs...)
+	}
+	for _, err := range errs {
+		slog.Error("Search failed", "engine", target, "error", err)
+	}
+	return results, nil
+}
+
+// searchConfig holds what every search of a run shares
+type searchConfig struct {
+	Targets []string
+	Options searcher.Options
+	Site    string       // Domain every search is restricted to with site:, empty for none
+	Domains domainFilter // Drops results from unwanted domains
+
+	SearchTimeout time.Duration // Time allowed for the search of a single target and query, 0 for no limit
+
+	Dedupe bool   // Keeps an image found by several engines only in the results of the preferred one
+	Prefer string // Comma-separated engine priority used by Dedupe
+
+	Expand           *expandOptions // Variants searched along with every query, nil for none
+	TranslateTo      string         // Comma-separated languages every query is also searched in, empty for none
+	Translator       translate.Translator
+	TranslateOptions translate.Options
+
+	Browsers chan struct{} // Limits how many browsers run at once
+	Proxies  *proxyPool
+	Stats    *summaryCollector
+	Display  *progress
+	Batch    bool // Labels the progress of every search with its query, since a run searches several
+}
+
+// variants returns the query followed by its expansions and translations, without duplicates
+func (c searchConfig) variants(ctx context.Context, query string) []string {
+	queries := []string{query}
+	if c.Expand != nil {
+		queries = expandQuery(ctx, query, *c.Expand)
+	}
+	if c.TranslateTo != "" {
+		for _, translation := range translateQuery(ctx, query, c.TranslateTo, c.Translator, c.TranslateOptions)[1:] {
+			if !containsFold(queries, translation) {
+				queries = append(queries, translation)
+			}
+		}
+	}
+	return queries
+}
+
+// searchBatch searches the queries of a batch with up to workers of them in flight at once and returns their
+// results in the order of the batch. Queries wait in a queue for a free slot, and their searches still share
+// the -max-browsers limit. Queries not started before ctx is cancelled are left out.
+func (c searchConfig) searchBatch(ctx context.Context, batch []batchQuery, workers int) []querySearch {
+	searches := make([]querySearch, len(batch))
+	searched := make([]bool, len(batch))
+	queue := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(max(workers, 1), len(batch)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				found, scrapedAt := c.search(ctx, batch[i])
+				searches[i] = querySearch{batchQuery: batch[i], Found: found, ScrapedAt: scrapedAt}
+				searched[i] = true
+			}
+		}()
+	}
+
+feed:
+	for i := range batch {
+		select {
+		case queue <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	var done []querySearch
+	for i, s := range searches {
+		if searched[i] {
+			done = append(done, s)
+		}
+	}
+	return done
+}
+
+// searchStream searches the queries as they arrive with up to workers of them in flight at once and calls fn
+// with every search as soon as it completes, one at a time. It returns once the channel is closed and the last
+// search is handled, or once ctx is cancelled.
+func (c searchConfig) searchStream(ctx context.Context, queries <-chan batchQuery, workers int, fn func(querySearch)) {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for w := 0; w < max(workers, 1); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case q, ok := <-queries:
+					if !ok {
+						return
+					}
+					found, scrapedAt := c.search(ctx, q)
+					mu.Lock()
+					fn(querySearch{batchQuery: q, Found: found, ScrapedAt: scrapedAt})
+					mu.Unlock()
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// search searches every target for the batch query and its variants concurrently and returns the results and the
+// time of the search per target. Every target is searched before anything is downloaded, so cross-engine dedupe sees
+// the complete result set regardless of completion order.
+func (c searchConfig) search(ctx context.Context, q batchQuery) (map[string][]searcher.Result, map[string]time.Time) {
+	query := q.Query
+	opts := c.Options
+	if q.Limit > 0 {
+		opts.Limit = q.Limit
+	}
+	queries := c.variants(ctx, query)
+	labels := make([]string, len(c.Targets))
+	for i, target := range c.Targets {
+		labels[i] = target
+		if c.Batch {
+			labels[i] = fmt.Sprintf("%s (%s)", target, query)
+		}
+	}
+
+	found := make(map[string][]searcher.Result)
+	scrapedAt := make(map[string]time.Time)
+	c.Display.searching(labels)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i, target := range c.Targets {
+		wg.Add(1)
+		go func(target, label string) {
+			defer wg.Done()
+
+			results, err := searchQueries(ctx, target, queries, c.Site, opts, c.SearchTimeout, c.Browsers, c.Proxies)
+			if c.Domains.active() {
+				kept := c.Domains.apply(results)
+				slog.Debug("Filtered results by domain", "engine", target, "query", query, "found", len(results), "kept", len(kept))
+				results = kept
+			}
+			// Every variant of the query returns up to the limit, so the merged results are cut to it, keeping
+			// those of the query as typed and of the earlier variants
+			results = searcher.LimitResults(results, opts.Limit)
+			c.Stats.searched(target, len(results), err)
+			c.Display.searched(label, len(results), err)
+			if err != nil {
+				slog.Error("Search failed", "engine", target, "query", query, "error", err)
+				return
+			}
+			if len(results) == 0 {
+				slog.Warn("No images found", "engine", target, "query", query)
+				return
+			}
+
+			mu.Lock()
+			found[target] = results
+			scrapedAt[target] = time.Now().UTC()
+			mu.Unlock()
+		}(target, labels[i])
+	}
+	wg.Wait()
+
+	if c.Dedupe {
+		found = dedupeResults(found, engineOrder(c.Targets, c.Prefer))
+	}
+	return found, scrapedAt
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/selman92/image-searcher/pkg/searcher"
+	"github.com/selman92/image-searcher/pkg/translate"
+)
+
+// variantEngine returns limit results per query, the first of them shared by every query
+type variantEngine struct{}
+
+func (variantEngine) Name() string {
+	return "test-variants"
+}
+
+func (variantEngine) Search(ctx context.Context, query string, opts searcher.Options) ([]searcher.Result, error) {
+	results := []searcher.Result{{URL: "https://example.com/shared.jpg"}}
+	for i := 1; len(results) < opts.Limit; i++ {
+		results = append(results, searcher.Result{URL: fmt.Sprintf("https://example.com/%s/%d.jpg", query, i)})
+	}
+	return results, nil
+}
+
+func init() {
+	searcher.Register(variantEngine{})
+}
+
+// suffixTranslator "translates" a query by appending the language code
+type suffixTranslator struct{}
+
+func (suffixTranslator) Name() string {
+	return "test-suffix"
+}
+
+func (suffixTranslator) Translate(ctx context.Context, text, target string, opts translate.Options) (string, error) {
+	return text + " " + target, nil
+}
+
+// testSearchConfig returns a search config for the variant engine with the limit
+func testSearchConfig(limit int) searchConfig {
+	return searchConfig{
+		Targets: []string{"test-variants"},
+		Options: searcher.Options{Limit: limit},
+		Stats:   newSummaryCollector(nil),
+		Display: newProgress(true),
+	}
+}
+
+func TestSearchLimitsTranslatedResults(t *testing.T) {
+	c := testSearchConfig(4)
+	c.TranslateTo = "de,ja"
+	c.Translator = suffixTranslator{}
+
+	found, _ := c.search(context.Background(), batchQuery{Query: "cats"})
+	results := found["test-variants"]
+	if len(results) != 4 {
+		t.Fatalf("got %d results for 3 queries, want the limit of 4", len(results))
+	}
+	for _, result := range results {
+		if result.Query != "cats" {
+			t.Errorf("got a result of %q before those of the original query ran out", result.Query)
+		}
+	}
+}
+
+func TestSearchLimitsExpandedResults(t *testing.T) {
+	c := testSearchConfig(3)
+	c.Expand = &expandOptions{Inflect: true, Expansions: map[string][]string{"cat": {"kitten"}}}
+
+	found, _ := c.search(context.Background(), batchQuery{Query: "black cat"})
+	results := found["test-variants"]
+	if len(results) != 3 {
+		t.Fatalf("got %d results for 3 variants, want the limit of 3", len(results))
+	}
+	seen := make(map[string]bool)
+	for _, result := range results {
+		if seen[result.URL] {
+			t.Errorf("got %s twice", result.URL)
+		}
+		seen[result.URL] = true
+		if result.Query != "black cat" {
+			t.Errorf("got a result of the variant %q before those of the query ran out", result.Query)
+		}
+	}
+}
+
+func TestSearchQueriesDropsRepeatedImages(t *testing.T) {
+	opts := searcher.Options{Limit: 3}
+	results, err := searchQueries(context.Background(), "test-variants", []string{"cat", "cats", "kitten"}, "", opts, 0, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every query returns the shared image and two of its own
+	if len(results) != 7 {
+		t.Fatalf("got %d results, want 7", len(results))
+	}
+	if results[0].URL != "https://example.com/shared.jpg" || results[0].Query != "cat" {
+		t.Errorf("got %s for %q first, want the shared image for the first query", results[0].URL, results[0].Query)
+	}
+}
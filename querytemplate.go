@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// queryVarPattern matches a {variable} in a query template
+var queryVarPattern = regexp.MustCompile(`\{([A-Za-z0-9_]+)\}`)
+
+// templateVars returns the variables of a query template in the order they first appear
+func templateVars(template string) []string {
+	var names []string
+	for _, match := range queryVarPattern.FindAllStringSubmatch(template, -1) {
+		if !slices.Contains(names, match[1]) {
+			names = append(names, match[1])
+		}
+	}
+	return names
+}
+
+// loadQueryVars reads the values of the variables of -vars, a comma-separated list of name=file entries. A file
+// without a name is allowed when the template has a single variable, so "{animal} in the wild" can use animals.txt.
+func loadQueryVars(spec, template string) (map[string][]string, error) {
+	names := templateVars(template)
+	if len(names) == 0 {
+		return nil, fmt.Errorf("invalid query template %q: -vars needs a {variable} in the query", template)
+	}
+
+	vars := make(map[string][]string)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, path, ok := strings.Cut(entry, "=")
+		if !ok {
+			if len(names) > 1 {
+				return nil, fmt.Errorf("invalid -vars entry %q: the query has several variables, use name=file", entry)
+			}
+			name, path = names[0], entry
+		}
+		if !slices.Contains(names, name) {
+			return nil, fmt.Errorf("invalid -vars entry %q: the query has no {%s}", entry, name)
+		}
+		if _, ok := vars[name]; ok {
+			return nil, fmt.Errorf("invalid -vars: {%s} is given more than once", name)
+		}
+		values, err := loadVarValues(path)
+		if err != nil {
+			return nil, err
+		}
+		vars[name] = values
+	}
+	for _, name := range names {
+		if _, ok := vars[name]; !ok {
+			return nil, fmt.Errorf("invalid -vars: no values for {%s}", name)
+		}
+	}
+	return vars, nil
+}
+
+// loadVarValues reads the values of a variable, one per line, ignoring blank lines and # comments
+func loadVarValues(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read variable values: %v", err)
+	}
+	defer f.Close()
+
+	var values []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		value := strings.TrimSpace(scanner.Text())
+		if value != "" && !strings.HasPrefix(value, "#") {
+			values = append(values, value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read variable values: %v", err)
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("no values in %s", path)
+	}
+	return values, nil
+}
+
+// expandTemplate returns a query for every combination of the variable values, varying the last variable fastest
+func expandTemplate(template string, vars map[string][]string) []string {
+	queries := []string{template}
+	for _, name := range templateVars(template) {
+		var next []string
+		for _, query := range queries {
+			for _, value := range vars[name] {
+				next = append(next, strings.ReplaceAll(query, "{"+name+"}", value))
+			}
+		}
+		queries = next
+	}
+	return queries
+}
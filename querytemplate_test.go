@@ -0,0 +1,92 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeVarValues writes the lines to a values file in dir and returns its path
+func writeVarValues(t *testing.T, dir, name string, lines ...string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestTemplateVars(t *testing.T) {
+	got := templateVars("{animal} in the {place}, a {animal} {Place_2} {not-a-var}")
+	if want := []string{"animal", "place", "Place_2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestExpandTemplate(t *testing.T) {
+	vars := map[string][]string{"animal": {"cat", "dog"}, "place": {"park", "snow", "city"}}
+	got := expandTemplate("{animal} in the {place}, {animal} photo", vars)
+	want := []string{
+		"cat in the park, cat photo", "cat in the snow, cat photo", "cat in the city, cat photo",
+		"dog in the park, dog photo", "dog in the snow, dog photo", "dog in the city, dog photo",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestLoadQueryVars(t *testing.T) {
+	dir := t.TempDir()
+	animals := writeVarValues(t, dir, "animals.txt", "# animals", "cat", "", "  dog  ")
+	places := writeVarValues(t, dir, "places.txt", "park")
+	empty := writeVarValues(t, dir, "empty.txt", "# nothing yet")
+
+	vars, err := loadQueryVars("animal="+animals+", place="+places, "{animal} in the {place}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string][]string{"animal": {"cat", "dog"}, "place": {"park"}}; !reflect.DeepEqual(vars, want) {
+		t.Errorf("got %v, want %v", vars, want)
+	}
+	// A single variable may take the file alone
+	if vars, err := loadQueryVars(animals, "{animal} in the wild"); err != nil || len(vars["animal"]) != 2 {
+		t.Errorf("got %v, %v for a file without a name", vars, err)
+	}
+
+	tests := []struct {
+		spec     string
+		template string
+		want     string
+	}{
+		{animals, "cats in the wild", "needs a {variable}"},
+		{animals, "{animal} in the {place}", "the query has several variables"},
+		{"color=" + animals, "{animal}", "the query has no {color}"},
+		{"animal=" + animals + ",animal=" + places, "{animal}", "{animal} is given more than once"},
+		{"animal=" + animals, "{animal} in the {place}", "no values for {place}"},
+		{"animal=" + empty, "{animal}", "no values in"},
+		{"animal=" + filepath.Join(dir, "missing.txt"), "{animal}", "failed to read variable values"},
+	}
+	for _, tt := range tests {
+		if _, err := loadQueryVars(tt.spec, tt.template); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("loadQueryVars(%q, %q): got error %v, want one containing %q", tt.spec, tt.template, err, tt.want)
+		}
+	}
+}
+
+func TestTemplateQueriesSkipsRepeatedFolders(t *testing.T) {
+	dir := t.TempDir()
+	colors := writeVarValues(t, dir, "colors.txt", "red", "red ", "blue")
+	queries, err := templateQueries("{color} car", colors, "images")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, q := range queries {
+		got = append(got, q.Folder)
+	}
+	if want := []string{filepath.Join("images", "red car"), filepath.Join("images", "blue car")}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got folders %q, want %q", got, want)
+	}
+}
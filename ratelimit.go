@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// hostLimiter limits downloads per host with a token bucket and a cap on parallel requests,
+// so result sets that mostly point at one CDN don't get the tool blocked
+type hostLimiter struct {
+	rate     float64 // Requests per second allowed per host, 0 for no limit
+	parallel int     // Requests in flight allowed per host, 0 for no limit
+
+	mu    sync.Mutex
+	hosts map[string]*hostBucket
+}
+
+// hostBucket is the limiter state of a single host
+type hostBucket struct {
+	slots  chan struct{}
+	tokens float64
+	last   time.Time
+}
+
+// newHostLimiter returns a limiter allowing rate requests per second and parallel requests in flight per host.
+// The bucket holds up to parallel tokens, so a host may receive that many requests at once after being idle.
+func newHostLimiter(rate float64, parallel int) *hostLimiter {
+	return &hostLimiter{rate: rate, parallel: parallel, hosts: make(map[string]*hostBucket)}
+}
+
+// bucket returns the state of the host, creating it with a full bucket on first use
+func (l *hostLimiter) bucket(host string) *hostBucket {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b, ok := l.hosts[host]
+	if !ok {
+		b = &hostBucket{tokens: l.burst(), last: time.Now()}
+		if l.parallel > 0 {
+			b.slots = make(chan struct{}, l.parallel)
+		}
+		l.hosts[host] = b
+	}
+	return b
+}
+
+// burst returns the bucket capacity
+func (l *hostLimiter) burst() float64 {
+	return float64(max(l.parallel, 1))
+}
+
+// wait blocks until a request to the host of rawURL may start and returns the function releasing it.
+// It returns ctx's error if ctx is cancelled while waiting.
+func (l *hostLimiter) wait(ctx context.Context, rawURL string) (func(), error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return func() {}, nil
+	}
+	b := l.bucket(u.Hostname())
+
+	release := func() {}
+	if b.slots != nil {
+		select {
+		case b.slots <- struct{}{}:
+			release = func() { <-b.slots }
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	if l.rate > 0 {
+		// Take a token, borrowing against future refills when the bucket is empty
+		l.mu.Lock()
+		now := time.Now()
+		b.tokens = min(l.burst(), b.tokens+now.Sub(b.last).Seconds()*l.rate)
+		b.last = now
+		b.tokens--
+		delay := time.Duration(-b.tokens / l.rate * float64(time.Second))
+		l.mu.Unlock()
+
+		if delay > 0 {
+			timer := time.NewTimer(delay)
+			defer timer.Stop()
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				release()
+				return nil, ctx.Err()
+			}
+		}
+	}
+
+	return release, nil
+}
+
+// bandwidthLimiter is a token bucket over bytes shared by all downloads, capping their aggregate throughput
+type bandwidthLimiter struct {
+	rate float64 // Bytes per second
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// newBandwidthLimiter returns a limiter allowing rate bytes per second with a burst of one second of traffic
+func newBandwidthLimiter(rate float64) *bandwidthLimiter {
+	return &bandwidthLimiter{rate: rate, tokens: rate, last: time.Now()}
+}
+
+// chunk returns the largest read that may be requested at once, so a single read never exceeds the burst
+func (l *bandwidthLimiter) chunk(n int) int {
+	return max(1, min(n, int(l.rate)))
+}
+
+// waitN blocks until n more bytes may be transferred or ctx is cancelled
+func (l *bandwidthLimiter) waitN(ctx context.Context, n int) error {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens = min(l.rate, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	l.tokens -= float64(n)
+	delay := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// throttledTransport wraps the bodies of all responses so reading them draws from the bandwidth limiter
+type throttledTransport struct {
+	base    http.RoundTripper
+	limiter *bandwidthLimiter
+}
+
+func (t *throttledTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = &throttledBody{ReadCloser: resp.Body, ctx: req.Context(), limiter: t.limiter}
+	return resp, nil
+}
+
+// throttledBody is a response body whose reads wait for the bandwidth limiter
+type throttledBody struct {
+	io.ReadCloser
+	ctx     context.Context
+	limiter *bandwidthLimiter
+}
+
+func (b *throttledBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p[:b.limiter.chunk(len(p))])
+	if n > 0 {
+		if waitErr := b.limiter.waitN(b.ctx, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
+
+// parseBandwidth parses a bandwidth like "5MB/s" into bytes per second. An empty value means no limit and returns 0.
+func parseBandwidth(value string) (float64, error) {
+	size, err := parseSize(strings.TrimSuffix(strings.TrimSpace(value), "/s"))
+	if err != nil {
+		return 0, fmt.Errorf("invalid bandwidth %q, expected a value like 5MB/s or 512KiB/s", value)
+	}
+	return float64(size), nil
+}
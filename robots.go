@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// robotsAgent is the product token matched against User-agent lines of robots.txt files.
+// Hosts without a group for it fall back to their * group.
+const robotsAgent = "image-searcher"
+
+// maxRobotsSize is how much of a robots.txt file is read, the limit crawlers must at least support by RFC 9309
+const maxRobotsSize = 500 << 10
+
+// robotsCache fetches the robots.txt of every image host once per run and answers whether URLs may be fetched
+type robotsCache struct {
+	client *http.Client
+
+	mu    sync.Mutex
+	hosts map[string]*robotsEntry
+}
+
+// robotsEntry is the robots.txt of a single host, ready once its fetch completes
+type robotsEntry struct {
+	ready chan struct{}
+	rules *robotsRules
+}
+
+// newRobotsCache returns a cache fetching robots.txt files with the client
+func newRobotsCache(client *http.Client) *robotsCache {
+	return &robotsCache{client: client, hosts: make(map[string]*robotsEntry)}
+}
+
+// allowed reports whether robots.txt allows fetching rawURL. The first call for a host fetches its robots.txt,
+// later calls for the same host wait for that fetch and reuse its rules.
+func (c *robotsCache) allowed(ctx context.Context, rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return true
+	}
+	origin := u.Scheme + "://" + u.Host
+
+	c.mu.Lock()
+	entry, ok := c.hosts[origin]
+	if !ok {
+		entry = &robotsEntry{ready: make(chan struct{})}
+		c.hosts[origin] = entry
+	}
+	c.mu.Unlock()
+
+	if !ok {
+		entry.rules = c.fetch(ctx, origin)
+		close(entry.ready)
+	}
+	select {
+	case <-entry.ready:
+	case <-ctx.Done():
+		return false
+	}
+
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return entry.rules.allows(path)
+}
+
+// fetch downloads and parses the robots.txt of the origin. As RFC 9309 asks, a missing file allows everything
+// and a file that can't be fetched because of a server or network error disallows everything.
+func (c *robotsCache) fetch(ctx context.Context, origin string) *robotsRules {
+	disallowAll := &robotsRules{rules: []robotsRule{{pattern: regexp.MustCompile(`^/`), length: 1}}}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, origin+"/robots.txt", nil)
+	if err != nil {
+		return disallowAll
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return disallowAll
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode <= 299:
+		return parseRobots(io.LimitReader(resp.Body, maxRobotsSize), robotsAgent)
+	case resp.StatusCode >= 400 && resp.StatusCode <= 499:
+		return &robotsRules{}
+	default:
+		return disallowAll
+	}
+}
+
+// robotsRules are the Allow and Disallow lines of the group applying to the crawler
+type robotsRules struct {
+	rules []robotsRule
+}
+
+// robotsRule is a single Allow or Disallow line
+type robotsRule struct {
+	allow   bool
+	pattern *regexp.Regexp
+	length  int // Length of the path pattern, the most specific matching rule wins
+}
+
+// allows reports whether the rules allow fetching the path. The longest matching pattern decides,
+// and Allow wins ties.
+func (r *robotsRules) allows(path string) bool {
+	if path == "/robots.txt" {
+		return true
+	}
+	allowed, longest := true, -1
+	for _, rule := range r.rules {
+		if !rule.pattern.MatchString(path) {
+			continue
+		}
+		if rule.length > longest || (rule.length == longest && rule.allow) {
+			allowed, longest = rule.allow, rule.length
+		}
+	}
+	return allowed
+}
+
+// parseRobots reads a robots.txt file and returns the rules of the groups naming the agent,
+// or of the * groups if none does. A group naming the agent without rules allows everything.
+func parseRobots(r io.Reader, agent string) *robotsRules {
+	var named, wildcard []robotsRule
+	var agents []string
+	hasNamed := false // Whether a group names the agent, even one without rules
+	inRules := false  // Whether the current group's User-agent lines are followed by rules yet
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if inRules {
+				agents, inRules = nil, false
+			}
+			agents = append(agents, strings.ToLower(value))
+			hasNamed = hasNamed || strings.EqualFold(value, agent)
+		case "allow", "disallow":
+			inRules = true
+			if value == "" {
+				continue
+			}
+			rule := robotsRule{allow: key == "allow", pattern: robotsPattern(value), length: len(value)}
+			for _, a := range agents {
+				if a == strings.ToLower(agent) {
+					named = append(named, rule)
+				} else if a == "*" {
+					wildcard = append(wildcard, rule)
+				}
+			}
+		}
+	}
+
+	if hasNamed {
+		return &robotsRules{rules: named}
+	}
+	return &robotsRules{rules: wildcard}
+}
+
+// robotsPattern compiles a robots.txt path pattern, where * matches any characters and a trailing $ anchors the end
+func robotsPattern(value string) *regexp.Regexp {
+	anchored := strings.HasSuffix(value, "$")
+	value = strings.TrimSuffix(value, "$")
+	expr := "^" + strings.ReplaceAll(regexp.QuoteMeta(value), `\*`, ".*")
+	if anchored {
+		expr += "$"
+	}
+	return regexp.MustCompile(expr)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testRobots = `# Example robots.txt
+User-agent: *
+Disallow: /private/
+Allow: /private/public/
+Disallow: /*.gif$
+Disallow: /search?q=
+
+User-agent: Image-Searcher
+User-agent: other-bot
+Disallow: /no-images/   # only for us
+Allow: /no-images/logo.png
+`
+
+func TestParseRobots(t *testing.T) {
+	wildcard := parseRobots(strings.NewReader(testRobots), "some-crawler")
+	named := parseRobots(strings.NewReader(testRobots), robotsAgent)
+
+	tests := []struct {
+		rules *robotsRules
+		path  string
+		want  bool
+	}{
+		{wildcard, "/cats.jpg", true},
+		{wildcard, "/private/cats.jpg", false},
+		{wildcard, "/private/public/cats.jpg", true},
+		{wildcard, "/img/cats.gif", false},
+		{wildcard, "/img/cats.gif?size=large", true},
+		{wildcard, "/search?q=cats", false},
+		{wildcard, "/robots.txt", true},
+		// The group naming the agent replaces the * group
+		{named, "/private/cats.jpg", true},
+		{named, "/no-images/cats.jpg", false},
+		{named, "/no-images/logo.png", true},
+	}
+	for _, tt := range tests {
+		if got := tt.rules.allows(tt.path); got != tt.want {
+			t.Errorf("allows(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestParseRobotsEmptyNamedGroup(t *testing.T) {
+	rules := parseRobots(strings.NewReader("User-agent: *\nDisallow: /\n\nUser-agent: image-searcher\nDisallow:\n"), robotsAgent)
+	if !rules.allows("/cats.jpg") {
+		t.Error("a group naming the agent without rules must allow everything")
+	}
+}
+
+func TestRobotsPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"/images", "/images/cat.jpg", true},
+		{"/images", "/img/cat.jpg", false},
+		{"/*.jpg$", "/a/b/cat.jpg", true},
+		{"/*.jpg$", "/a/b/cat.jpg?x=1", false},
+		{"/a.b(c)", "/a.b(c)/d", true},
+		{"/a.b(c)", "/axb(c)", false},
+	}
+	for _, tt := range tests {
+		if got := robotsPattern(tt.pattern).MatchString(tt.path); got != tt.want {
+			t.Errorf("%q matching %q = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestRobotsCacheFetch(t *testing.T) {
+	tests := []struct {
+		status int
+		body   string
+		want   bool
+	}{
+		{http.StatusOK, "User-agent: *\nDisallow: /cats/\n", false},
+		{http.StatusNotFound, "", true},
+		{http.StatusServiceUnavailable, "", false},
+	}
+	for _, tt := range tests {
+		requests := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			w.WriteHeader(tt.status)
+			w.Write([]byte(tt.body))
+		}))
+		cache := newRobotsCache(server.Client())
+		first := cache.allowed(context.Background(), server.URL+"/cats/1.jpg")
+		second := cache.allowed(context.Background(), server.URL+"/cats/2.jpg")
+		server.Close()
+
+		if first != tt.want || second != tt.want {
+			t.Errorf("status %d: got %v and %v, want %v", tt.status, first, second, tt.want)
+		}
+		if requests != 1 {
+			t.Errorf("status %d: robots.txt fetched %d times, want once", tt.status, requests)
+		}
+	}
+}
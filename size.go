@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// sizePattern matches a byte size like "5MB", "512KiB" or "100000"
+var sizePattern = regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*([KMG]i?)?B?$`)
+
+// sizeUnits are the multipliers of the unit prefixes accepted by parseSize
+var sizeUnits = map[string]float64{
+	"":   1,
+	"K":  1e3,
+	"M":  1e6,
+	"G":  1e9,
+	"Ki": 1 << 10,
+	"Mi": 1 << 20,
+	"Gi": 1 << 30,
+}
+
+// parseSize parses a byte size like "20MB" or "1.5GiB". An empty value means no limit and returns 0.
+func parseSize(value string) (int64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+	match := sizePattern.FindStringSubmatch(value)
+	if match == nil {
+		return 0, fmt.Errorf("invalid size %q, expected a value like 20MB or 512KiB", value)
+	}
+	number, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q: %v", value, err)
+	}
+	return int64(number * sizeUnits[match[2]]), nil
+}
+
+// formatSize formats a byte count with a decimal unit, e.g. "1.5MB"
+func formatSize(bytes float64) string {
+	for _, unit := range []string{"G", "M", "K"} {
+		if bytes >= sizeUnits[unit] {
+			return fmt.Sprintf("%.1f%sB", bytes/sizeUnits[unit], unit)
+		}
+	}
+	return fmt.Sprintf("%.0fB", bytes)
+}
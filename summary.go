@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// Exit codes of a run, so scripts can tell what happened. Invalid flags and setup errors exit with 1.
+const (
+	exitOK             = 0
+	exitNoResults      = 2   // The searches worked but found no images
+	exitPartialFailure = 3   // Some searches or downloads failed, but images were saved
+	exitFailure        = 4   // Every search failed, or every download that was tried failed
+	exitTimedOut       = 124 // The run was stopped by -total-timeout, as timeout(1) reports
+	exitInterrupted    = 130 // The run was stopped by SIGINT or SIGTERM, as shells report for Ctrl-C
+)
+
+// engineSummary counts what happened to the results of a single engine
+type engineSummary struct {
+	Engine      string `json:"engine"`
+	Found       int    `json:"found"`
+	Saved       int    `json:"saved"`
+	Skipped     int    `json:"skipped"`
+	Failed      int    `json:"failed"`
+	Invalid     int    `json:"invalid"`
+	Duplicates  int    `json:"duplicates"`
+	Bytes       int64  `json:"bytes"`
+	SearchError string `json:"search_error,omitempty"`
+}
+
+// runSummary is the end-of-run report printed by -summary and written by -summary-file
+type runSummary struct {
+	Engines     []*engineSummary `json:"engines"`
+	Total       engineSummary    `json:"total"`
+	Duration    float64          `json:"duration_seconds"`
+	Interrupted bool             `json:"interrupted"`
+	TimedOut    bool             `json:"timed_out"`
+	ExitCode    int              `json:"exit_code"`
+}
+
+// summaryCollector counts search results and download outcomes per engine. It is safe for concurrent use.
+type summaryCollector struct {
+	start time.Time
+
+	mu      sync.Mutex
+	engines []*engineSummary
+	byName  map[string]*engineSummary
+}
+
+// newSummaryCollector returns a collector listing the engines in the order given
+func newSummaryCollector(engines []string) *summaryCollector {
+	c := &summaryCollector{start: time.Now(), byName: make(map[string]*engineSummary)}
+	for _, engine := range engines {
+		c.engine(engine)
+	}
+	return c
+}
+
+// engine returns the counts of the engine, adding it on first use. The caller must hold c.mu
+// or be the only user of c.
+func (c *summaryCollector) engine(name string) *engineSummary {
+	summary, ok := c.byName[name]
+	if !ok {
+		summary = &engineSummary{Engine: name}
+		c.byName[name] = summary
+		c.engines = append(c.engines, summary)
+	}
+	return summary
+}
+
+// searched records the outcome of an engine's search
+func (c *summaryCollector) searched(engine string, found int, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	summary := c.engine(engine)
+	summary.Found += found
+	if err != nil {
+		summary.SearchError = err.Error()
+	}
+}
+
+// add records the outcome of a download job
+func (c *summaryCollector) add(outcome downloadOutcome) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	summary := c.engine(outcome.Job.Result.Engine)
+	if outcome.Replaced != "" {
+		// The image was counted as saved before it gave way to an identical one of a preferred engine
+		summary.Saved--
+		summary.Bytes -= outcome.Bytes
+		summary.Duplicates++
+		return
+	}
+	switch outcome.Status {
+	case statusSaved:
+		summary.Saved++
+		summary.Bytes += outcome.Bytes
+	case statusSkipped:
+		summary.Skipped++
+	case statusFailed:
+		summary.Failed++
+	case statusInvalid:
+		summary.Invalid++
+	case statusDuplicate:
+		summary.Duplicates++
+	}
+}
+
+// summary returns the totals and the exit code of the run. A non-nil stopped is the error of the run's context,
+// which was cancelled by an interrupt or ran out of time.
+func (c *summaryCollector) summary(stopped error) runSummary {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := runSummary{Total: engineSummary{Engine: "total"}, Duration: time.Since(c.start).Seconds()}
+	s.TimedOut = errors.Is(stopped, context.DeadlineExceeded)
+	s.Interrupted = stopped != nil && !s.TimedOut
+	searchErrors := 0
+	for _, e := range c.engines {
+		copied := *e
+		s.Engines = append(s.Engines, &copied)
+		s.Total.Found += e.Found
+		s.Total.Saved += e.Saved
+		s.Total.Skipped += e.Skipped
+		s.Total.Failed += e.Failed
+		s.Total.Invalid += e.Invalid
+		s.Total.Duplicates += e.Duplicates
+		s.Total.Bytes += e.Bytes
+		if e.SearchError != "" {
+			searchErrors++
+		}
+	}
+
+	kept := s.Total.Saved + s.Total.Skipped + s.Total.Duplicates
+	switch {
+	case s.TimedOut:
+		s.ExitCode = exitTimedOut
+	case s.Interrupted:
+		s.ExitCode = exitInterrupted
+	case len(c.engines) > 0 && searchErrors == len(c.engines) && s.Total.Found == 0:
+		s.ExitCode = exitFailure
+	case s.Total.Found == 0:
+		s.ExitCode = exitNoResults
+	case kept == 0 && s.Total.Failed+s.Total.Invalid > 0:
+		s.ExitCode = exitFailure
+	case searchErrors > 0 || s.Total.Failed > 0:
+		s.ExitCode = exitPartialFailure
+	default:
+		s.ExitCode = exitOK
+	}
+	return s
+}
+
+// writeTable prints the summary as an aligned table
+func (s runSummary) writeTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Engine\tFound\tSaved\tSkipped\tFailed\tInvalid\tDuplicates\tBytes")
+	for _, e := range append(s.Engines, &s.Total) {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%d\t%d\t%d\n", e.Engine, e.Found, e.Saved, e.Skipped, e.Failed, e.Invalid, e.Duplicates, e.Bytes)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	for _, e := range s.Engines {
+		if e.SearchError != "" {
+			fmt.Fprintf(w, "Search on %s failed: %s\n", e.Engine, e.SearchError)
+		}
+	}
+	if s.Interrupted {
+		fmt.Fprintln(w, "The run was interrupted, the counts cover the work done until then")
+	}
+	if s.TimedOut {
+		fmt.Fprintln(w, "The run reached -total-timeout, the counts cover the work done until then")
+	}
+	_, err := fmt.Fprintf(w, "Finished in %s\n", time.Duration(s.Duration*float64(time.Second)).Round(time.Millisecond))
+	return err
+}
+
+// writeJSON prints the summary as an indented JSON object
+func (s runSummary) writeJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(s)
+}
+
+// writeSummaryFile writes the summary as JSON to path
+func writeSummaryFile(path string, s runSummary) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to write summary: %v", err)
+	}
+	defer f.Close()
+	if err := s.writeJSON(f); err != nil {
+		return fmt.Errorf("failed to write summary: %v", err)
+	}
+	return nil
+}
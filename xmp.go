@@ -0,0 +1,302 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"strings"
+
+	"github.com/selman92/image-searcher/pkg/searcher"
+)
+
+// errNoXMPSupport is returned for file types provenance can't be embedded into
+var errNoXMPSupport = errors.New("embedding metadata is only supported for JPEG, PNG and WebP")
+
+// xmpNamespace is the namespace of the properties that have no standard XMP equivalent
+const xmpNamespace = "https://github.com/selman92/image-searcher/ns/1.0/"
+
+// embedProvenance writes the source URL, page, query, engine and credits of the result into the saved
+// image as an XMP packet, replacing any XMP the file had. IPTC-aware tools read the query as keywords and
+// the source URL as the IPTC Source. The file is rewritten through a temporary file.
+func embedProvenance(path, contentType string, result searcher.Result) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to embed metadata: %v", err)
+	}
+
+	packet := xmpPacket(result)
+	switch contentType {
+	case "image/jpeg":
+		data, err = jpegWithXMP(data, packet)
+	case "image/png":
+		data, err = pngWithXMP(data, packet)
+	case "image/webp":
+		data, err = webpWithXMP(data, packet)
+	default:
+		err = errNoXMPSupport
+	}
+	if err != nil {
+		return fmt.Errorf("failed to embed metadata: %w", err)
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to embed metadata: %v", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to embed metadata: %v", err)
+	}
+	return nil
+}
+
+// xmpPacket returns the XMP packet describing where the result came from
+func xmpPacket(result searcher.Result) []byte {
+	var b strings.Builder
+	property := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "   <%s>%s</%s>\n", name, xmlEscape(value), name)
+		}
+	}
+
+	b.WriteString("<?xpacket begin=\"\uFEFF\" id=\"W5M0MpCehiHzreSzNTczkc9d\"?>\n")
+	b.WriteString("<x:xmpmeta xmlns:x=\"adobe:ns:meta/\">\n")
+	b.WriteString(" <rdf:RDF xmlns:rdf=\"http://www.w3.org/1999/02/22-rdf-syntax-ns#\">\n")
+	b.WriteString("  <rdf:Description rdf:about=\"\"\n")
+	b.WriteString("    xmlns:dc=\"http://purl.org/dc/elements/1.1/\"\n")
+	b.WriteString("    xmlns:photoshop=\"http://ns.adobe.com/photoshop/1.0/\"\n")
+	b.WriteString("    xmlns:imagesearcher=\"" + xmpNamespace + "\">\n")
+	property("dc:source", result.URL)
+	property("photoshop:Source", result.URL)
+	if result.Title != "" {
+		fmt.Fprintf(&b, "   <dc:title><rdf:Alt><rdf:li xml:lang=\"x-default\">%s</rdf:li></rdf:Alt></dc:title>\n", xmlEscape(result.Title))
+	}
+	if result.Author != "" {
+		fmt.Fprintf(&b, "   <dc:creator><rdf:Seq><rdf:li>%s</rdf:li></rdf:Seq></dc:creator>\n", xmlEscape(result.Author))
+	}
+	if result.License != "" {
+		fmt.Fprintf(&b, "   <dc:rights><rdf:Alt><rdf:li xml:lang=\"x-default\">%s</rdf:li></rdf:Alt></dc:rights>\n", xmlEscape(result.License))
+	}
+	if result.Query != "" {
+		fmt.Fprintf(&b, "   <dc:subject><rdf:Bag><rdf:li>%s</rdf:li></rdf:Bag></dc:subject>\n", xmlEscape(result.Query))
+	}
+	property("imagesearcher:query", result.Query)
+	property("imagesearcher:engine", result.Engine)
+	property("imagesearcher:pageURL", result.PageURL)
+	property("imagesearcher:licenseURL", result.LicenseURL)
+	b.WriteString("  </rdf:Description>\n")
+	b.WriteString(" </rdf:RDF>\n")
+	b.WriteString("</x:xmpmeta>\n")
+	b.WriteString("<?xpacket end=\"w\"?>")
+	return []byte(b.String())
+}
+
+// xmlEscape escapes text for an XML element
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// jpegXMPHeader starts the APP1 segment holding XMP in a JPEG file
+var jpegXMPHeader = []byte("http://ns.adobe.com/xap/1.0/\x00")
+
+// jpegWithXMP returns the JPEG with its XMP APP1 segments replaced by one holding the packet,
+// placed after the JFIF and Exif segments that must come first
+func jpegWithXMP(data, packet []byte) ([]byte, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, errors.New("not a JPEG file")
+	}
+	if len(jpegXMPHeader)+len(packet)+2 > 0xFFFF {
+		return nil, errors.New("XMP packet too large for a JPEG segment")
+	}
+
+	var out bytes.Buffer
+	out.Write(data[:2])
+	inserted := false
+	insert := func() {
+		out.Write([]byte{0xFF, 0xE1})
+		binary.Write(&out, binary.BigEndian, uint16(len(jpegXMPHeader)+len(packet)+2))
+		out.Write(jpegXMPHeader)
+		out.Write(packet)
+		inserted = true
+	}
+
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return nil, errors.New("corrupt JPEG segment")
+		}
+		marker := data[pos+1]
+		if marker == 0xDA { // Start of scan, the compressed image data follows
+			break
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			return nil, errors.New("corrupt JPEG segment")
+		}
+		segment := data[pos:end]
+		isApp0, isApp1 := marker == 0xE0, marker == 0xE1
+		if !inserted && !isApp0 && !(isApp1 && !bytes.HasPrefix(segment[4:], jpegXMPHeader)) {
+			insert()
+		}
+		if !(isApp1 && bytes.HasPrefix(segment[4:], jpegXMPHeader)) {
+			out.Write(segment)
+		}
+		pos = end
+	}
+	if !inserted {
+		insert()
+	}
+	out.Write(data[pos:])
+	return out.Bytes(), nil
+}
+
+// pngSignature starts every PNG file
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// pngXMPKeyword is the keyword of the iTXt chunk holding XMP in a PNG file
+const pngXMPKeyword = "XML:com.adobe.xmp"
+
+// pngWithXMP returns the PNG with its XMP iTXt chunk replaced by one holding the packet, placed before the image data
+func pngWithXMP(data, packet []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, errors.New("not a PNG file")
+	}
+
+	var out bytes.Buffer
+	out.Write(pngSignature)
+	inserted := false
+	pos := len(pngSignature)
+	for pos+12 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		end := pos + 12 + length
+		if length < 0 || end > len(data) {
+			return nil, errors.New("corrupt PNG chunk")
+		}
+		chunkType := string(data[pos+4 : pos+8])
+		body := data[pos+8 : pos+8+length]
+		if !inserted && (chunkType == "IDAT" || chunkType == "IEND") {
+			// keyword, null, compression flag and method, empty language tag and translated keyword
+			text := append([]byte(pngXMPKeyword+"\x00\x00\x00\x00\x00"), packet...)
+			writePNGChunk(&out, "iTXt", text)
+			inserted = true
+		}
+		if !(chunkType == "iTXt" && bytes.HasPrefix(body, []byte(pngXMPKeyword+"\x00"))) {
+			out.Write(data[pos:end])
+		}
+		pos = end
+	}
+	if !inserted {
+		return nil, errors.New("PNG file has no image data")
+	}
+	return out.Bytes(), nil
+}
+
+// writePNGChunk writes a chunk with its length and CRC
+func writePNGChunk(out *bytes.Buffer, chunkType string, body []byte) {
+	binary.Write(out, binary.BigEndian, uint32(len(body)))
+	crc := crc32.NewIEEE()
+	crc.Write([]byte(chunkType))
+	crc.Write(body)
+	out.WriteString(chunkType)
+	out.Write(body)
+	binary.Write(out, binary.BigEndian, crc.Sum32())
+}
+
+// webpWithXMP returns the WebP with an "XMP " chunk holding the packet, converting simple files to the extended
+// format that can carry metadata
+func webpWithXMP(data, packet []byte) ([]byte, error) {
+	if len(data) < 20 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, errors.New("not a WebP file")
+	}
+
+	type chunk struct {
+		id   string
+		body []byte
+	}
+	var chunks []chunk
+	for pos := 12; pos+8 <= len(data); {
+		size := int(binary.LittleEndian.Uint32(data[pos+4:]))
+		end := pos + 8 + size
+		if size < 0 || end > len(data) {
+			return nil, errors.New("corrupt WebP chunk")
+		}
+		chunks = append(chunks, chunk{id: string(data[pos : pos+4]), body: data[pos+8 : end]})
+		pos = end + size%2 // Chunks are padded to an even size
+	}
+	if len(chunks) == 0 {
+		return nil, errors.New("WebP file has no image data")
+	}
+
+	if chunks[0].id != "VP8X" {
+		// A simple file has a single VP8 or VP8L chunk, the extended header needs its canvas size
+		width, height, alpha, err := webpImageSize(chunks[0].id, chunks[0].body)
+		if err != nil {
+			return nil, err
+		}
+		header := make([]byte, 10)
+		if alpha {
+			header[0] |= 0x10
+		}
+		putUint24(header[4:], uint32(width-1))
+		putUint24(header[7:], uint32(height-1))
+		chunks = append([]chunk{{id: "VP8X", body: header}}, chunks...)
+	}
+	header := append([]byte(nil), chunks[0].body...)
+	header[0] |= 0x04 // XMP metadata present
+	chunks[0].body = header
+
+	var body bytes.Buffer
+	body.WriteString("WEBP")
+	for _, c := range chunks {
+		if c.id == "XMP " {
+			continue
+		}
+		writeWebPChunk(&body, c.id, c.body)
+	}
+	writeWebPChunk(&body, "XMP ", packet)
+
+	var out bytes.Buffer
+	out.WriteString("RIFF")
+	binary.Write(&out, binary.LittleEndian, uint32(body.Len()))
+	out.Write(body.Bytes())
+	return out.Bytes(), nil
+}
+
+// webpImageSize reads the canvas size from a VP8 or VP8L bitstream, and whether it may have transparency
+func webpImageSize(id string, body []byte) (width, height int, alpha bool, err error) {
+	switch {
+	case id == "VP8 " && len(body) >= 10 && body[3] == 0x9D && body[4] == 0x01 && body[5] == 0x2A:
+		width = int(binary.LittleEndian.Uint16(body[6:]) & 0x3FFF)
+		height = int(binary.LittleEndian.Uint16(body[8:]) & 0x3FFF)
+		return width, height, false, nil
+	case id == "VP8L" && len(body) >= 5 && body[0] == 0x2F:
+		bits := binary.LittleEndian.Uint32(body[1:])
+		width = int(bits&0x3FFF) + 1
+		height = int(bits>>14&0x3FFF) + 1
+		return width, height, bits>>28&1 == 1, nil
+	default:
+		return 0, 0, false, errors.New("unsupported WebP bitstream")
+	}
+}
+
+// writeWebPChunk writes a RIFF chunk, padded to an even size
+func writeWebPChunk(out *bytes.Buffer, id string, body []byte) {
+	out.WriteString(id)
+	binary.Write(out, binary.LittleEndian, uint32(len(body)))
+	out.Write(body)
+	if len(body)%2 == 1 {
+		out.WriteByte(0)
+	}
+}
+
+// putUint24 writes a little-endian 24-bit value
+func putUint24(b []byte, v uint32) {
+	b[0], b[1], b[2] = byte(v), byte(v>>8), byte(v>>16)
+}